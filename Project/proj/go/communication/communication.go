@@ -3,9 +3,12 @@ package network
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 )
 
+const MaxRequestSize = 1 << 20
+
 func ReceiveVehicleData(conn net.Conn) (map[string]map[string]interface{}, error) {
 	lenBuf := make([]byte, 4)
 	_, err := conn.Read(lenBuf)
@@ -60,3 +63,47 @@ func SendCommands(conn net.Conn, commands map[string]interface{}) error {
 
 	return nil
 }
+
+func ReceiveRequest(conn net.Conn, request interface{}) error {
+	lenBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lenBuf); err != nil {
+		return fmt.Errorf("failed to read message length: %w", err)
+	}
+
+	msgLen := (int(lenBuf[0]) << 24) | (int(lenBuf[1]) << 16) | (int(lenBuf[2]) << 8) | int(lenBuf[3])
+	if msgLen > MaxRequestSize {
+		return fmt.Errorf("message of %d bytes exceeds the %d byte limit", msgLen, MaxRequestSize)
+	}
+
+	buf := make([]byte, msgLen)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+
+	if err := json.Unmarshal(buf, request); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return nil
+}
+
+func SendResponse(conn net.Conn, response interface{}) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	msgLen := len(data)
+	lenBuf := []byte{
+		byte(msgLen >> 24),
+		byte(msgLen >> 16),
+		byte(msgLen >> 8),
+		byte(msgLen),
+	}
+
+	if _, err := conn.Write(append(lenBuf, data...)); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return nil
+}
@@ -2,4 +2,4 @@ module sumo
 
 go 1.24.2
 
-require github.com/gorilla/websocket v1.5.3 // indirect
+require github.com/gorilla/websocket v1.5.3
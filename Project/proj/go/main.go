@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"net"
 	"os"
@@ -16,12 +17,44 @@ import (
 
 func main() {
 	benchmarkMode := flag.Bool("benchmark", false, "Run in benchmark mode")
-	algorithmType := flag.String("algorithm", "custom", "Traffic algorithm to use (custom or sumo)")
+	algorithmType := flag.String("algorithm", "custom", "Traffic algorithm to use (custom, sumo, aim, fixed, actuated, maxpressure, optimal or external)")
 	duration := flag.Int("duration", 1000, "Benchmark duration in steps")
+	policyFile := flag.String("policy", "", "Priority scoring policy file (JSON)")
+	ecoDriving := flag.Bool("eco", false, "Enable eco-driving approach speed smoothing")
+	penetrationRate := flag.Float64("penetration", 1.0, "Fraction of vehicles that are connected and follow commands (0-1)")
+	v2xLatency := flag.Float64("v2x-latency", 0, "V2X message latency in seconds")
+	v2xJitter := flag.Float64("v2x-jitter", 0, "Additional random V2X latency in seconds")
+	v2xLoss := flag.Float64("v2x-loss", 0, "V2X packet loss probability (0-1)")
+	v2xRange := flag.Float64("v2x-range", 0, "V2X communication range around intersections in meters (0 for unlimited)")
+	envMode := flag.Bool("env", false, "Serve the reinforcement-learning environment on port 5556 over the built-in junction simulator instead of connecting to SUMO")
 	flag.Parse()
 
+	if *envMode {
+		serveEnvironment(manager.NewEnvironment)
+		return
+	}
+
 	tm := manager.NewTrafficManager()
-	tm.UseCustomAlgorithm = (*algorithmType == "custom")
+	if err := tm.SetAlgorithm(*algorithmType); err != nil {
+		log.Fatalf("failed to set algorithm: %v", err)
+	}
+	if *v2xLoss < 0 || *v2xLoss > 1 {
+		log.Fatalf("v2x loss must be between 0 and 1, got %v", *v2xLoss)
+	}
+	tm.EcoDriving.Enabled = *ecoDriving
+	tm.Connectivity.PenetrationRate = *penetrationRate
+	tm.V2X.Latency = *v2xLatency
+	tm.V2X.Jitter = *v2xJitter
+	tm.V2X.LossRate = *v2xLoss
+	tm.V2X.Range = *v2xRange
+
+	if *policyFile != "" {
+		policy, err := manager.LoadPriorityPolicy(*policyFile)
+		if err != nil {
+			log.Fatalf("failed to load priority policy: %v", err)
+		}
+		tm.SetPriorityPolicy(policy)
+	}
 
 	os.MkdirAll("statistics", 0755)
 	os.MkdirAll("web/static/css", 0755)
@@ -62,23 +95,89 @@ func main() {
 		tm.StartBenchmark(*duration, *algorithmType)
 	}
 
+	if tm.Algorithm == manager.AlgorithmExternal {
+		env := manager.NewSumoEnvironment(tm, func() error {
+			return stepSumo(tm, conn)
+		})
+		serveEnvironment(func() *manager.Environment { return env })
+		return
+	}
+
 	for {
-		vehicleData, err := network.ReceiveVehicleData(conn)
-		if err != nil {
-			log.Printf("err receiving data: %v", err)
+		if err := stepSumo(tm, conn); err != nil {
+			log.Printf("err %v", err)
 			break
 		}
 
-		tm.UpdateVehicleData(vehicleData)
-		tm.Update()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func stepSumo(tm *manager.TrafficManager, conn net.Conn) error {
+	vehicleData, err := network.ReceiveVehicleData(conn)
+	if err != nil {
+		return fmt.Errorf("receiving data: %w", err)
+	}
+
+	tm.UpdateVehicleData(vehicleData)
+	tm.Update()
 
-		commands := tm.PrepareCommands()
-		err = network.SendCommands(conn, commands)
+	commands := tm.PrepareCommands()
+	if err := network.SendCommands(conn, commands); err != nil {
+		return fmt.Errorf("sending commands: %w", err)
+	}
+
+	return nil
+}
+
+func serveEnvironment(newEnvironment func() *manager.Environment) {
+	listener, err := net.Listen("tcp", "localhost:5556")
+	if err != nil {
+		log.Fatalf("failed to listen on port 5556: %v", err)
+	}
+	defer listener.Close()
+
+	log.Printf("environment waiting for trainers on port 5556...")
+
+	for {
+		conn, err := listener.Accept()
 		if err != nil {
-			log.Printf("err sending commands: %v", err)
-			break
+			log.Printf("failed to accept connection: %v", err)
+			continue
 		}
 
-		time.Sleep(10 * time.Millisecond)
+		log.Printf("trainer connected from %s", conn.RemoteAddr())
+		handleEnvironmentClient(conn, newEnvironment())
+	}
+}
+
+func handleEnvironmentClient(conn net.Conn, env *manager.Environment) {
+	defer conn.Close()
+	defer env.Close()
+
+	for {
+		var request manager.EnvironmentRequest
+		if err := network.ReceiveRequest(conn, &request); err != nil {
+			log.Printf("trainer disconnected: %v", err)
+			return
+		}
+
+		if request.Command == "close" {
+			log.Printf("trainer closed the environment")
+			return
+		}
+
+		var response interface{}
+		result, err := env.Handle(request)
+		if err != nil {
+			response = map[string]string{"error": err.Error()}
+		} else {
+			response = result
+		}
+
+		if err := network.SendResponse(conn, response); err != nil {
+			log.Printf("err sending environment response: %v", err)
+			return
+		}
 	}
 }
@@ -0,0 +1,522 @@
+package manager
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type AIMController struct {
+	TileSize             float64
+	TimeSlot             float64
+	SafetyBuffer         float64
+	RequestDistance      float64
+	MaxCounterOfferDelay float64
+	ArrivalTolerance     float64
+	VehicleLength        float64
+	ApproachAccel        float64
+	ApproachDecel        float64
+
+	Grants     map[string]*AIMGrant
+	GrantCount int
+	DenyCount  int
+
+	tiles map[aimCell]string
+}
+
+type aimCell struct {
+	junctionID string
+	col        int
+	row        int
+	slot       int
+}
+
+type AIMRequest struct {
+	RequesterID  string
+	JunctionID   string
+	EdgeFrom     string
+	Direction    string
+	VehicleIDs   []string
+	Offsets      []float64
+	ArrivalTime  float64
+	ArrivalSpeed float64
+	Predecessor  string
+}
+
+type AIMGrant struct {
+	AIMRequest
+	ExitTime float64
+	cells    []aimCell
+}
+
+type AIMResponse struct {
+	Granted      bool
+	Grant        *AIMGrant
+	CounterOffer float64
+}
+
+func NewAIMController() *AIMController {
+	return &AIMController{
+		TileSize:             1.5,
+		TimeSlot:             0.5,
+		SafetyBuffer:         0.75,
+		RequestDistance:      80.0,
+		MaxCounterOfferDelay: 60.0,
+		ArrivalTolerance:     1.0,
+		VehicleLength:        5.0,
+		ApproachAccel:        2.5,
+		ApproachDecel:        4.5,
+
+		Grants: make(map[string]*AIMGrant),
+		tiles:  make(map[aimCell]string),
+	}
+}
+
+func (c *AIMController) release(requesterID string) {
+	grant, exists := c.Grants[requesterID]
+	if !exists {
+		return
+	}
+
+	for _, cell := range grant.cells {
+		if c.tiles[cell] == requesterID {
+			delete(c.tiles, cell)
+		}
+	}
+
+	delete(c.Grants, requesterID)
+}
+
+func (c *AIMController) expire(now float64) {
+	for id, grant := range c.Grants {
+		if grant.ExitTime < now {
+			c.release(id)
+		}
+	}
+
+	currentSlot := int(math.Floor(now / c.TimeSlot))
+	for cell := range c.tiles {
+		if cell.slot < currentSlot {
+			delete(c.tiles, cell)
+		}
+	}
+}
+
+func (tm *TrafficManager) ManageAIM() {
+	c := tm.AIM
+	now := tm.SimulationTime()
+
+	c.expire(now)
+	granted := make(map[string]bool)
+
+	for _, request := range tm.collectAIMRequests(now) {
+		if !tm.isAIMRequestConnected(request) {
+			tm.reserveAIMObstacle(request)
+			continue
+		}
+
+		if tm.conflictsWithPreemption(request.JunctionID, request.EdgeFrom, request.Direction) {
+			c.release(request.RequesterID)
+			tm.applyAIMTarget(request, 0, request.ArrivalSpeed, false, now)
+			continue
+		}
+
+		if request.Predecessor != "" && !granted[request.Predecessor] {
+			if _, exists := c.Grants[request.RequesterID]; exists {
+				log.Printf("aim: released %s at %s, %s ahead in its lane has no grant",
+					request.RequesterID, request.JunctionID, request.Predecessor)
+				c.release(request.RequesterID)
+			}
+			tm.applyAIMTarget(request, 0, request.ArrivalSpeed, false, now)
+			continue
+		}
+
+		if grant, exists := c.Grants[request.RequesterID]; exists {
+			late := request.ArrivalTime > grant.ArrivalTime+c.ArrivalTolerance
+			sameMembers := len(grant.VehicleIDs) == len(request.VehicleIDs)
+
+			if !late && sameMembers {
+				granted[request.RequesterID] = true
+				tm.applyAIMTarget(request, grant.ArrivalTime, grant.ArrivalSpeed, true, now)
+				continue
+			}
+
+			c.release(request.RequesterID)
+		}
+
+		response := tm.RequestAIMReservation(request)
+		if response.Granted {
+			granted[request.RequesterID] = true
+			tm.applyAIMTarget(request, response.Grant.ArrivalTime, response.Grant.ArrivalSpeed, true, now)
+		} else {
+			tm.applyAIMTarget(request, response.CounterOffer, request.ArrivalSpeed, false, now)
+		}
+	}
+
+	for _, vehicle := range tm.Vehicles {
+		if len(vehicle.Edge) > 0 && vehicle.Edge[0] == ':' {
+			vehicle.DesiredSpeed = math.Max(vehicle.Speed, tm.getMovementSpeedLimit(vehicle.TurnDirection))
+		}
+	}
+}
+
+func (tm *TrafficManager) collectAIMRequests(now float64) []AIMRequest {
+	c := tm.AIM
+	junctions := tm.getEdgeJunctions()
+	requests := make([]AIMRequest, 0)
+
+	platoonIDs := make([]string, 0, len(tm.Platoons))
+	for id := range tm.Platoons {
+		platoonIDs = append(platoonIDs, id)
+	}
+	sort.Strings(platoonIDs)
+
+	for _, platoonID := range platoonIDs {
+		platoon := tm.Platoons[platoonID]
+		leader, exists := tm.Vehicles[platoon.LeaderID]
+		if !exists {
+			continue
+		}
+
+		members := make([]*models.Vehicle, 0, len(platoon.VehicleIDs))
+		for _, v := range tm.getOrderedPlatoonVehicles(platoon) {
+			if v.Edge == leader.Edge {
+				members = append(members, v)
+			}
+		}
+
+		if request, ok := tm.buildAIMRequest(platoonID, members, junctions, now); ok {
+			requests = append(requests, request)
+		}
+	}
+
+	vehicleIDs := make([]string, 0, len(tm.Vehicles))
+	for id := range tm.Vehicles {
+		if _, inPlatoon := tm.VehicleToPlatoon[id]; !inPlatoon {
+			vehicleIDs = append(vehicleIDs, id)
+		}
+	}
+	sort.Strings(vehicleIDs)
+
+	for _, id := range vehicleIDs {
+		if request, ok := tm.buildAIMRequest(id, []*models.Vehicle{tm.Vehicles[id]}, junctions, now); ok {
+			requests = append(requests, request)
+		}
+	}
+
+	requests = tm.orderAIMRequestsByLane(requests)
+
+	for id := range c.Grants {
+		found := false
+		for _, request := range requests {
+			if request.RequesterID == id {
+				found = true
+				break
+			}
+		}
+
+		if !found && !tm.isAIMGrantInProgress(c.Grants[id], now) {
+			c.release(id)
+		}
+	}
+
+	return requests
+}
+
+func (tm *TrafficManager) orderAIMRequestsByLane(requests []AIMRequest) []AIMRequest {
+	distances := make([]float64, len(requests))
+	lanes := make(map[string][]int)
+
+	for i, request := range requests {
+		leader := tm.Vehicles[request.VehicleIDs[0]]
+		distances[i] = tm.estimateDistanceToIntersection(leader, nil)
+
+		lane := request.EdgeFrom + "|" + leader.Lane
+		lanes[lane] = append(lanes[lane], i)
+	}
+
+	arrivals := make([]float64, len(requests))
+	for _, indices := range lanes {
+		sort.SliceStable(indices, func(a, b int) bool {
+			return distances[indices[a]] < distances[indices[b]]
+		})
+
+		arrival := math.Inf(-1)
+		for k, i := range indices {
+			if k > 0 && tm.isAIMRequestConnected(requests[indices[k-1]]) {
+				requests[i].Predecessor = requests[indices[k-1]].RequesterID
+			}
+
+			arrival = math.Max(arrival, requests[i].ArrivalTime)
+			arrivals[i] = arrival
+		}
+	}
+
+	order := make([]int, len(requests))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if ci, cj := tm.isAIMRequestConnected(requests[i]), tm.isAIMRequestConnected(requests[j]); ci != cj {
+			return cj
+		}
+		if arrivals[i] != arrivals[j] {
+			return arrivals[i] < arrivals[j]
+		}
+		return distances[i] < distances[j]
+	})
+
+	ordered := make([]AIMRequest, 0, len(requests))
+	for _, i := range order {
+		ordered = append(ordered, requests[i])
+	}
+
+	return ordered
+}
+
+func (tm *TrafficManager) isAIMRequestConnected(request AIMRequest) bool {
+	vehicle, exists := tm.Vehicles[request.VehicleIDs[0]]
+	return !exists || vehicle.Connected
+}
+
+func (tm *TrafficManager) reserveAIMObstacle(request AIMRequest) {
+	c := tm.AIM
+	c.release(request.RequesterID)
+
+	vehicle := tm.Vehicles[request.VehicleIDs[0]]
+	if front := tm.FindVehicleAhead(vehicle); front != nil && front.Edge == vehicle.Edge {
+		return
+	}
+
+	cells, exitTime, ok := tm.simulateAIMTrajectory(request)
+	if !ok {
+		return
+	}
+
+	for _, cell := range cells {
+		owner, taken := c.tiles[cell]
+		if !taken || owner == request.RequesterID {
+			continue
+		}
+
+		if grant, exists := c.Grants[owner]; exists && tm.isAIMRequestConnected(grant.AIMRequest) {
+			log.Printf("aim: released %s at %s, conflicts with non-connected %s",
+				owner, request.JunctionID, request.RequesterID)
+			c.release(owner)
+		}
+	}
+
+	for _, cell := range cells {
+		if _, taken := c.tiles[cell]; !taken {
+			c.tiles[cell] = request.RequesterID
+		}
+	}
+
+	c.Grants[request.RequesterID] = &AIMGrant{
+		AIMRequest: request,
+		ExitTime:   exitTime,
+		cells:      cells,
+	}
+}
+
+func (tm *TrafficManager) isAIMGrantInProgress(grant *AIMGrant, now float64) bool {
+	return now >= grant.ArrivalTime-tm.AIM.ArrivalTolerance && now <= grant.ExitTime
+}
+
+func (tm *TrafficManager) buildAIMRequest(requesterID string, members []*models.Vehicle,
+	junctions map[string]string, now float64) (AIMRequest, bool) {
+
+	if len(members) == 0 {
+		return AIMRequest{}, false
+	}
+
+	leader := members[0]
+	junctionID, exists := junctions[leader.Edge]
+	if !exists {
+		return AIMRequest{}, false
+	}
+
+	distance := tm.estimateDistanceToIntersection(leader, nil)
+	if distance < 0 || distance > tm.AIM.RequestDistance {
+		return AIMRequest{}, false
+	}
+
+	leader.TurnDirection = tm.determineTurnDirection(leader, leader.NextEdge)
+	if tm.getMovementPath(leader.Edge, leader.TurnDirection) == nil {
+		return AIMRequest{}, false
+	}
+
+	reachable := math.Sqrt(leader.Speed*leader.Speed + 2*tm.AIM.ApproachAccel*distance)
+	arrivalSpeed := math.Min(tm.getMovementSpeedLimit(leader.TurnDirection), reachable)
+	averageSpeed := math.Max((leader.Speed+arrivalSpeed)/2.0, 1.0)
+
+	request := AIMRequest{
+		RequesterID:  requesterID,
+		JunctionID:   junctionID,
+		EdgeFrom:     leader.Edge,
+		Direction:    leader.TurnDirection,
+		VehicleIDs:   make([]string, 0, len(members)),
+		Offsets:      make([]float64, 0, len(members)),
+		ArrivalTime:  now + distance/averageSpeed,
+		ArrivalSpeed: arrivalSpeed,
+	}
+
+	for _, v := range members {
+		v.TurnDirection = leader.TurnDirection
+		request.VehicleIDs = append(request.VehicleIDs, v.ID)
+		request.Offsets = append(request.Offsets, leader.Pos-v.Pos)
+	}
+
+	return request, true
+}
+
+func (tm *TrafficManager) RequestAIMReservation(request AIMRequest) AIMResponse {
+	c := tm.AIM
+
+	cells, exitTime, ok := tm.simulateAIMTrajectory(request)
+	if !ok {
+		return AIMResponse{}
+	}
+
+	if c.cellsAvailable(cells, request.RequesterID) {
+		grant := &AIMGrant{
+			AIMRequest: request,
+			ExitTime:   exitTime,
+			cells:      cells,
+		}
+
+		for _, cell := range cells {
+			c.tiles[cell] = request.RequesterID
+		}
+
+		c.Grants[request.RequesterID] = grant
+		c.GrantCount++
+
+		log.Printf("aim: granted %s at %s (%s %s, %d vehicles) arriving at %.1fs",
+			request.RequesterID, request.JunctionID, request.EdgeFrom, request.Direction,
+			len(request.VehicleIDs), request.ArrivalTime)
+
+		return AIMResponse{Granted: true, Grant: grant}
+	}
+
+	c.DenyCount++
+
+	for delay := c.TimeSlot; delay <= c.MaxCounterOfferDelay; delay += c.TimeSlot {
+		trial := request
+		trial.ArrivalTime = request.ArrivalTime + delay
+
+		trialCells, _, ok := tm.simulateAIMTrajectory(trial)
+		if ok && c.cellsAvailable(trialCells, request.RequesterID) {
+			log.Printf("aim: denied %s at %s, counter-offer arrival at %.1fs",
+				request.RequesterID, request.JunctionID, trial.ArrivalTime)
+
+			return AIMResponse{CounterOffer: trial.ArrivalTime}
+		}
+	}
+
+	log.Printf("aim: denied %s at %s, no counter-offer within %.0fs",
+		request.RequesterID, request.JunctionID, c.MaxCounterOfferDelay)
+
+	return AIMResponse{}
+}
+
+func (c *AIMController) cellsAvailable(cells []aimCell, requesterID string) bool {
+	for _, cell := range cells {
+		if owner, taken := c.tiles[cell]; taken && owner != requesterID {
+			return false
+		}
+	}
+	return true
+}
+
+func (tm *TrafficManager) simulateAIMTrajectory(request AIMRequest) ([]aimCell, float64, bool) {
+	c := tm.AIM
+
+	path := tm.getMovementPath(request.EdgeFrom, request.Direction)
+	minBound, maxBound, hasBounds := tm.getJunctionBounds(request.JunctionID)
+	if path == nil || !hasBounds || request.ArrivalSpeed <= 0 {
+		return nil, 0, false
+	}
+
+	cols := int(math.Ceil((maxBound.X - minBound.X) / c.TileSize))
+	rows := int(math.Ceil((maxBound.Y - minBound.Y) / c.TileSize))
+	length := pathLength(path)
+
+	seen := make(map[aimCell]bool)
+	cells := make([]aimCell, 0)
+	exitTime := request.ArrivalTime
+
+	for i := range request.VehicleIDs {
+		entry := request.ArrivalTime + request.Offsets[i]/request.ArrivalSpeed
+		duration := (length + c.VehicleLength) / request.ArrivalSpeed
+		exitTime = math.Max(exitTime, entry+duration)
+
+		for t := entry; t <= entry+duration; t += c.TimeSlot / 2.0 {
+			front := (t - entry) * request.ArrivalSpeed
+			slot := int(math.Floor(t / c.TimeSlot))
+
+			for s := front - c.VehicleLength; s <= front; s += c.TileSize / 2.0 {
+				if s < 0 || s > length {
+					continue
+				}
+
+				p := pointAlongPath(path, s)
+				minCol := int(math.Floor((p.X - c.SafetyBuffer - minBound.X) / c.TileSize))
+				maxCol := int(math.Floor((p.X + c.SafetyBuffer - minBound.X) / c.TileSize))
+				minRow := int(math.Floor((p.Y - c.SafetyBuffer - minBound.Y) / c.TileSize))
+				maxRow := int(math.Floor((p.Y + c.SafetyBuffer - minBound.Y) / c.TileSize))
+
+				for col := max(minCol, 0); col <= min(maxCol, cols-1); col++ {
+					for row := max(minRow, 0); row <= min(maxRow, rows-1); row++ {
+						cell := aimCell{junctionID: request.JunctionID, col: col, row: row, slot: slot}
+						if !seen[cell] {
+							seen[cell] = true
+							cells = append(cells, cell)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return cells, exitTime, true
+}
+
+func (tm *TrafficManager) applyAIMTarget(request AIMRequest, arrivalTime, arrivalSpeed float64, granted bool, now float64) {
+	c := tm.AIM
+
+	for i, vid := range request.VehicleIDs {
+		vehicle, exists := tm.Vehicles[vid]
+		if !exists {
+			continue
+		}
+
+		distance := tm.estimateDistanceToIntersection(vehicle, nil)
+		if distance < 0 {
+			continue
+		}
+
+		stopSpeed := math.Sqrt(2 * c.ApproachDecel * math.Max(distance-2.0, 0))
+
+		if arrivalTime <= 0 {
+			vehicle.DesiredSpeed = math.Min(vehicle.Speed, stopSpeed)
+			continue
+		}
+
+		remaining := arrivalTime + request.Offsets[i]/math.Max(arrivalSpeed, 0.1) - now
+		targetSpeed := arrivalSpeed
+		if remaining > 0.1 {
+			targetSpeed = math.Min(distance/remaining, tm.MaxPlatoonSpeed)
+		}
+
+		if !granted {
+			targetSpeed = math.Min(targetSpeed, stopSpeed)
+		}
+
+		vehicle.DesiredSpeed = math.Max(0.0, targetSpeed)
+	}
+}
@@ -0,0 +1,381 @@
+package manager
+
+import (
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type ArrivalPredictor struct {
+	Horizon           float64
+	BinSize           float64
+	RateWindow        float64
+	SaturationHeadway float64
+	FreeFlowSpeed     float64
+
+	Forecasts map[string]*JunctionForecast
+	Rates     map[string]float64
+
+	seen    map[string]bool
+	entries map[string][]float64
+	started float64
+}
+
+type JunctionForecast struct {
+	JunctionID string                       `json:"junction"`
+	Time       float64                      `json:"time"`
+	Horizon    float64                      `json:"horizon"`
+	Approaches map[string]*ApproachForecast `json:"approaches"`
+}
+
+type ApproachForecast struct {
+	Edge        string             `json:"edge"`
+	ArrivalRate float64            `json:"arrival_rate"`
+	EntryDelay  float64            `json:"entry_delay"`
+	Arrivals    []PredictedArrival `json:"arrivals"`
+	Platoons    []PredictedPlatoon `json:"platoons"`
+	Bins        []ForecastBin      `json:"bins"`
+}
+
+type PredictedArrival struct {
+	VehicleID string  `json:"vehicle"`
+	PlatoonID string  `json:"platoon,omitempty"`
+	Direction string  `json:"direction"`
+	Distance  float64 `json:"distance"`
+	ETA       float64 `json:"eta"`
+}
+
+type PredictedPlatoon struct {
+	ID        string  `json:"id"`
+	Size      int     `json:"size"`
+	Direction string  `json:"direction"`
+	ETA       float64 `json:"eta"`
+}
+
+type ForecastBin struct {
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Known    int     `json:"known"`
+	Expected float64 `json:"expected"`
+}
+
+func NewArrivalPredictor() *ArrivalPredictor {
+	return &ArrivalPredictor{
+		Horizon:           60.0,
+		BinSize:           5.0,
+		RateWindow:        120.0,
+		SaturationHeadway: 2.0,
+		FreeFlowSpeed:     13.89,
+		Forecasts:         make(map[string]*JunctionForecast),
+		Rates:             make(map[string]float64),
+		seen:              make(map[string]bool),
+		entries:           make(map[string][]float64),
+		started:           -1,
+	}
+}
+
+func (tm *TrafficManager) PredictArrivals() {
+	ap := tm.Prediction
+	now := tm.SimulationTime()
+	junctions := tm.getEdgeJunctions()
+
+	tm.updateArrivalRates()
+
+	forecasts := make(map[string]*JunctionForecast)
+	for _, junctionID := range tm.getJunctionIDs() {
+		forecast := &JunctionForecast{
+			JunctionID: junctionID,
+			Time:       now,
+			Horizon:    ap.Horizon,
+			Approaches: make(map[string]*ApproachForecast),
+		}
+
+		for _, edge := range tm.getJunctionApproaches(junctionID) {
+			forecast.Approaches[edge] = &ApproachForecast{
+				Edge:        edge,
+				ArrivalRate: ap.Rates[edge],
+				EntryDelay:  tm.getEdgeLengths()[edge] / ap.FreeFlowSpeed,
+				Arrivals:    make([]PredictedArrival, 0),
+				Platoons:    make([]PredictedPlatoon, 0),
+			}
+		}
+		forecasts[junctionID] = forecast
+	}
+
+	for _, vehicle := range tm.Vehicles {
+		edge, distance, direction, ok := tm.findUpcomingApproach(vehicle)
+		if !ok {
+			continue
+		}
+
+		approach := forecasts[junctions[edge]].Approaches[edge]
+		approach.Arrivals = append(approach.Arrivals, PredictedArrival{
+			VehicleID: vehicle.ID,
+			PlatoonID: tm.VehicleToPlatoon[vehicle.ID],
+			Direction: direction,
+			Distance:  distance,
+			ETA:       now + tm.estimateFreeArrivalDuration(vehicle, distance, direction),
+		})
+	}
+
+	for _, forecast := range forecasts {
+		for _, approach := range forecast.Approaches {
+			tm.completeApproachForecast(approach, now)
+		}
+	}
+
+	ap.Forecasts = forecasts
+}
+
+func (tm *TrafficManager) updateArrivalRates() {
+	ap := tm.Prediction
+	now := tm.SimulationTime()
+	present := make(map[string]bool, len(tm.Vehicles))
+
+	if ap.started < 0 {
+		ap.started = now
+	}
+
+	for id, vehicle := range tm.Vehicles {
+		present[id] = true
+		if ap.seen[id] {
+			continue
+		}
+		ap.seen[id] = true
+
+		if edge, _, _, ok := tm.findUpcomingApproach(vehicle); ok {
+			ap.entries[edge] = append(ap.entries[edge], now)
+		}
+	}
+
+	for id := range ap.seen {
+		if !present[id] {
+			delete(ap.seen, id)
+		}
+	}
+
+	window := math.Min(ap.RateWindow, math.Max(now-ap.started, tm.StepLength))
+	for _, edge := range tm.getApproachEdges() {
+		entries := ap.entries[edge]
+		for len(entries) > 0 && entries[0] <= now-window {
+			entries = entries[1:]
+		}
+		ap.entries[edge] = entries
+		ap.Rates[edge] = float64(len(entries)) / window
+	}
+}
+
+func (tm *TrafficManager) findUpcomingApproach(vehicle *models.Vehicle) (string, float64, string, bool) {
+	junctions := tm.getEdgeJunctions()
+	lengths := tm.getEdgeLengths()
+
+	if _, isApproach := junctions[vehicle.Edge]; isApproach {
+		distance := tm.estimateDistanceToIntersection(vehicle, nil)
+		if distance < 0 {
+			return "", 0, "", false
+		}
+		return vehicle.Edge, distance, tm.getVehicleDirection(vehicle), true
+	}
+
+	length, known := lengths[vehicle.Edge]
+	if !known {
+		return "", 0, "", false
+	}
+
+	distance := length - vehicle.Pos
+	for i := indexOf(vehicle.Route, vehicle.Edge) + 1; i > 0 && i < len(vehicle.Route); i++ {
+		edge := vehicle.Route[i]
+
+		if _, isApproach := junctions[edge]; isApproach {
+			direction := ""
+			if i+1 < len(vehicle.Route) {
+				direction = tm.getMovementDirection(edge, vehicle.Route[i+1])
+			}
+			return edge, distance + lengths[edge], direction, true
+		}
+
+		if _, known := lengths[edge]; !known {
+			break
+		}
+		distance += lengths[edge]
+	}
+
+	return "", 0, "", false
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func (tm *TrafficManager) getMovementDirection(edgeFrom, exitEdge string) string {
+	for _, direction := range []string{models.TurnLeft, models.TurnStraight, models.TurnRight} {
+		if tm.getMovementExitEdge(edgeFrom, direction) == exitEdge {
+			return direction
+		}
+	}
+	return ""
+}
+
+func (tm *TrafficManager) estimateFreeArrivalDuration(vehicle *models.Vehicle, distance float64, direction string) float64 {
+	vt := tm.getVehicleType(vehicle)
+	crossingSpeed := tm.Prediction.FreeFlowSpeed
+	if direction != "" {
+		crossingSpeed = tm.getMovementSpeedLimit(direction)
+	}
+
+	maxSpeed := math.Max(math.Max(tm.Prediction.FreeFlowSpeed, crossingSpeed), vehicle.Speed)
+	finalSpeed := math.Min(crossingSpeed, maxSpeed)
+
+	return earliestArrivalDuration(distance, vehicle.Speed, finalSpeed, vt.MaxAccel, vt.ComfortDecel, maxSpeed)
+}
+
+func (tm *TrafficManager) completeApproachForecast(approach *ApproachForecast, now float64) {
+	ap := tm.Prediction
+
+	sort.Slice(approach.Arrivals, func(i, j int) bool {
+		if approach.Arrivals[i].Distance != approach.Arrivals[j].Distance {
+			return approach.Arrivals[i].Distance < approach.Arrivals[j].Distance
+		}
+		return approach.Arrivals[i].VehicleID < approach.Arrivals[j].VehicleID
+	})
+
+	for i := 1; i < len(approach.Arrivals); i++ {
+		previous := approach.Arrivals[i-1].ETA
+		approach.Arrivals[i].ETA = math.Max(approach.Arrivals[i].ETA, previous+ap.SaturationHeadway)
+	}
+
+	platoons := make(map[string]int)
+	for _, arrival := range approach.Arrivals {
+		if arrival.PlatoonID == "" {
+			continue
+		}
+
+		if index, exists := platoons[arrival.PlatoonID]; exists {
+			approach.Platoons[index].Size++
+			continue
+		}
+
+		platoons[arrival.PlatoonID] = len(approach.Platoons)
+		approach.Platoons = append(approach.Platoons, PredictedPlatoon{
+			ID:        arrival.PlatoonID,
+			Size:      1,
+			Direction: arrival.Direction,
+			ETA:       arrival.ETA,
+		})
+	}
+
+	bins := int(math.Ceil(ap.Horizon / ap.BinSize))
+	approach.Bins = make([]ForecastBin, bins)
+	for i := range approach.Bins {
+		start := now + float64(i)*ap.BinSize
+		end := math.Min(start+ap.BinSize, now+ap.Horizon)
+
+		approach.Bins[i] = ForecastBin{
+			Start:    start,
+			End:      end,
+			Expected: approach.ArrivalRate * math.Max(end-math.Max(start, now+approach.EntryDelay), 0),
+		}
+	}
+
+	for _, arrival := range approach.Arrivals {
+		index := int((arrival.ETA - now) / ap.BinSize)
+		if index < 0 || index >= bins {
+			continue
+		}
+		approach.Bins[index].Known++
+		approach.Bins[index].Expected++
+	}
+}
+
+func (tm *TrafficManager) GetArrivalForecasts() map[string]*JunctionForecast {
+	return tm.Prediction.Forecasts
+}
+
+func (tm *TrafficManager) GetApproachForecast(junctionID, edge string) *ApproachForecast {
+	forecast, exists := tm.Prediction.Forecasts[junctionID]
+	if !exists {
+		return nil
+	}
+	return forecast.Approaches[edge]
+}
+
+func (tm *TrafficManager) summarizeArrivalForecast() map[string]map[string]float64 {
+	summary := make(map[string]map[string]float64)
+	for junctionID, forecast := range tm.Prediction.Forecasts {
+		summary[junctionID] = make(map[string]float64)
+		for edge := range forecast.Approaches {
+			summary[junctionID][edge] = tm.ExpectedArrivals(junctionID, edge, forecast.Horizon)
+		}
+	}
+	return summary
+}
+
+func (tm *TrafficManager) ExpectedArrivals(junctionID, edge string, within float64) float64 {
+	approach := tm.GetApproachForecast(junctionID, edge)
+	if approach == nil {
+		return 0
+	}
+
+	now := tm.SimulationTime()
+	expected := approach.ArrivalRate * math.Max(within-approach.EntryDelay, 0)
+	for _, arrival := range approach.Arrivals {
+		if arrival.ETA-now <= within {
+			expected++
+		}
+	}
+
+	return expected
+}
+
+func (tm *TrafficManager) ExpectedUpstreamArrivals(movement models.Movement, within float64) float64 {
+	junctionID := tm.getEdgeJunctions()[movement.Edge]
+	approach := tm.GetApproachForecast(junctionID, movement.Edge)
+	if approach == nil {
+		return 0
+	}
+
+	now := tm.SimulationTime()
+	length := tm.getEdgeLengths()[movement.Edge]
+	expected := 0.0
+	known, matching := 0, 0
+
+	for _, arrival := range approach.Arrivals {
+		if arrival.Direction == "" {
+			continue
+		}
+		known++
+		if arrival.Direction != movement.Direction {
+			continue
+		}
+		matching++
+
+		if arrival.Distance > length && arrival.ETA-now <= within {
+			expected++
+		}
+	}
+
+	share := 0.0
+	if known > 0 {
+		share = float64(matching) / float64(known)
+	} else if movements := tm.countApproachMovements(junctionID, movement.Edge); movements > 0 {
+		share = 1 / float64(movements)
+	}
+
+	return expected + share*approach.ArrivalRate*math.Max(within-approach.EntryDelay, 0)
+}
+
+func (tm *TrafficManager) countApproachMovements(junctionID, edge string) int {
+	count := 0
+	for _, movement := range tm.getJunctionMovements(junctionID) {
+		if movement.Edge == edge {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,357 @@
+package manager
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type ArrivalScheduler struct {
+	Horizon          float64
+	ClearanceTime    float64
+	MinCruiseSpeed   float64
+	TrackingError    float64
+	ProfileTolerance float64
+
+	Windows map[string]*CrossingWindow
+
+	profileLimits map[string]float64
+}
+
+type CrossingWindow struct {
+	GroupID       string
+	JunctionID    string
+	EdgeFrom      string
+	Direction     string
+	VehicleIDs    []string
+	Start         float64
+	End           float64
+	CrossingSpeed float64
+	Profile       SpeedProfile
+}
+
+type SpeedProfile struct {
+	StartTime    float64
+	InitialSpeed float64
+	CruiseSpeed  float64
+	FinalSpeed   float64
+	Accel        float64
+	Decel        float64
+	AccelTime    float64
+	CruiseTime   float64
+	DecelTime    float64
+}
+
+func NewArrivalScheduler() *ArrivalScheduler {
+	return &ArrivalScheduler{
+		Horizon:          150.0,
+		ClearanceTime:    1.5,
+		MinCruiseSpeed:   2.0,
+		TrackingError:    1.5,
+		ProfileTolerance: 1.0,
+		Windows:          make(map[string]*CrossingWindow),
+		profileLimits:    make(map[string]float64),
+	}
+}
+
+func (p SpeedProfile) Duration() float64 {
+	return p.AccelTime + p.CruiseTime + p.DecelTime
+}
+
+func (p SpeedProfile) SpeedAt(t float64) float64 {
+	elapsed := t - p.StartTime
+	switch {
+	case elapsed <= 0:
+		return p.InitialSpeed
+	case elapsed < p.AccelTime:
+		return p.InitialSpeed + p.Accel*elapsed
+	case elapsed < p.AccelTime+p.CruiseTime:
+		return p.CruiseSpeed
+	case elapsed < p.Duration():
+		return p.CruiseSpeed + p.Decel*(elapsed-p.AccelTime-p.CruiseTime)
+	default:
+		return p.FinalSpeed
+	}
+}
+
+func changeTime(from, to, accel, decel float64) (float64, float64) {
+	if to >= from {
+		return (to - from) / accel, accel
+	}
+	return (from - to) / decel, -decel
+}
+
+func planSpeedProfile(start, distance, initialSpeed, finalSpeed, duration,
+	accel, decel, minSpeed, maxSpeed float64) (SpeedProfile, float64) {
+
+	best := SpeedProfile{}
+	bestError := math.Inf(1)
+
+	for cruise := minSpeed; cruise <= maxSpeed; cruise += 0.05 {
+		t1, a1 := changeTime(initialSpeed, cruise, accel, decel)
+		t3, a3 := changeTime(cruise, finalSpeed, accel, decel)
+		t2 := duration - t1 - t3
+		if t2 < 0 {
+			continue
+		}
+
+		travelled := (initialSpeed+cruise)/2*t1 + cruise*t2 + (cruise+finalSpeed)/2*t3
+		if err := math.Abs(travelled - distance); err < bestError {
+			bestError = err
+			best = SpeedProfile{
+				StartTime:    start,
+				InitialSpeed: initialSpeed,
+				CruiseSpeed:  cruise,
+				FinalSpeed:   finalSpeed,
+				Accel:        a1,
+				Decel:        a3,
+				AccelTime:    t1,
+				CruiseTime:   t2,
+				DecelTime:    t3,
+			}
+		}
+	}
+
+	return best, bestError
+}
+
+func earliestArrivalDuration(distance, initialSpeed, finalSpeed, accel, decel, maxSpeed float64) float64 {
+	accelDistance := (maxSpeed*maxSpeed - initialSpeed*initialSpeed) / (2 * accel)
+	decelDistance := (maxSpeed*maxSpeed - finalSpeed*finalSpeed) / (2 * decel)
+
+	if accelDistance+decelDistance <= distance {
+		return (maxSpeed-initialSpeed)/accel + (maxSpeed-finalSpeed)/decel +
+			(distance-accelDistance-decelDistance)/maxSpeed
+	}
+
+	peakSquared := (distance + initialSpeed*initialSpeed/(2*accel) + finalSpeed*finalSpeed/(2*decel)) /
+		(1/(2*accel) + 1/(2*decel))
+	peak := math.Max(math.Sqrt(math.Max(peakSquared, 0)), math.Max(initialSpeed, finalSpeed))
+
+	return (peak-initialSpeed)/accel + (peak-finalSpeed)/decel
+}
+
+func (tm *TrafficManager) ScheduleArrivals() {
+	as := tm.Arrivals
+	now := tm.SimulationTime()
+	as.profileLimits = make(map[string]float64)
+
+	for id, window := range as.Windows {
+		if window.End < now {
+			delete(as.Windows, id)
+		}
+	}
+
+	groups := tm.collectApproachGroups(as.Horizon)
+	active := make(map[string]bool)
+
+	for _, group := range groups {
+		leader := group[0]
+		groupID := tm.getApproachGroupID(leader)
+		active[groupID] = true
+
+		window, exists := as.Windows[groupID]
+		if exists && !tm.isWindowStillFeasible(window, leader, now) {
+			log.Printf("arrival scheduler: window for %s at %.1fs no longer feasible, rescheduling",
+				groupID, window.Start)
+			delete(as.Windows, groupID)
+			exists = false
+		}
+
+		if !exists {
+			window = tm.assignCrossingWindow(groupID, group, now)
+			if window == nil {
+				continue
+			}
+		}
+
+		tm.followCrossingWindow(window, leader, now)
+	}
+
+	for id, window := range as.Windows {
+		if !active[id] && now < window.Start {
+			delete(as.Windows, id)
+		}
+	}
+}
+
+func (tm *TrafficManager) getApproachGroupID(leader *models.Vehicle) string {
+	if platoonID, inPlatoon := tm.VehicleToPlatoon[leader.ID]; inPlatoon {
+		return platoonID
+	}
+	return leader.ID
+}
+
+func (tm *TrafficManager) collectApproachGroups(horizon float64) [][]*models.Vehicle {
+	junctions := tm.getEdgeJunctions()
+	groups := make([][]*models.Vehicle, 0)
+	grouped := make(map[string]bool)
+
+	ids := make([]string, 0, len(tm.Vehicles))
+	for id := range tm.Vehicles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		vehicle := tm.Vehicles[id]
+		if grouped[id] {
+			continue
+		}
+
+		if _, isApproach := junctions[vehicle.Edge]; !isApproach {
+			continue
+		}
+
+		group := []*models.Vehicle{vehicle}
+		if platoonID, inPlatoon := tm.VehicleToPlatoon[id]; inPlatoon {
+			platoon, exists := tm.Platoons[platoonID]
+			if !exists {
+				continue
+			}
+
+			group = group[:0]
+			for _, member := range tm.getOrderedPlatoonVehicles(platoon) {
+				if member.Edge == vehicle.Edge {
+					group = append(group, member)
+				}
+			}
+		}
+
+		if len(group) == 0 || grouped[group[0].ID] {
+			continue
+		}
+
+		for _, member := range group {
+			grouped[member.ID] = true
+		}
+
+		distance := tm.estimateDistanceToIntersection(group[0], nil)
+		if distance < 0 || distance > horizon {
+			continue
+		}
+
+		groups = append(groups, group)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return tm.estimateDistanceToIntersection(groups[i][0], nil) < tm.estimateDistanceToIntersection(groups[j][0], nil)
+	})
+
+	return groups
+}
+
+func (tm *TrafficManager) assignCrossingWindow(groupID string, group []*models.Vehicle, now float64) *CrossingWindow {
+	as := tm.Arrivals
+	leader := group[0]
+	vt := tm.getVehicleType(leader)
+
+	junctionID := tm.getEdgeJunctions()[leader.Edge]
+	direction := tm.getVehicleDirection(leader)
+	path := tm.getMovementPath(leader.Edge, direction)
+	if path == nil {
+		return nil
+	}
+
+	distance := tm.estimateDistanceToIntersection(leader, nil)
+	crossingSpeed := tm.getMovementSpeedLimit(direction)
+	maxSpeed := math.Max(math.Max(tm.MaxPlatoonSpeed, crossingSpeed), leader.Speed)
+
+	accel, decel := tm.profileLimits(vt)
+	earliest := now + earliestArrivalDuration(distance, leader.Speed, crossingSpeed, accel, decel, maxSpeed)
+
+	tail := group[len(group)-1]
+	occupancy := (pathLength(path) + leader.Pos - tail.Pos + tm.getVehicleType(tail).Length) / crossingSpeed
+
+	start := earliest
+	for changed := leader.Connected; changed; {
+		changed = false
+		for _, other := range as.Windows {
+			if other.JunctionID != junctionID ||
+				tm.areMovementsCompatible(other.EdgeFrom, other.Direction, leader.Edge, direction) {
+				continue
+			}
+
+			if start < other.End+as.ClearanceTime && start+occupancy+as.ClearanceTime > other.Start {
+				start = other.End + as.ClearanceTime
+				changed = true
+			}
+		}
+	}
+
+	profile, err := planSpeedProfile(now, distance, leader.Speed, crossingSpeed, start-now,
+		accel, decel, as.MinCruiseSpeed, maxSpeed)
+	if err > as.ProfileTolerance {
+		profile, err = planSpeedProfile(now, distance, leader.Speed, crossingSpeed, start-now,
+			accel, decel, 0, maxSpeed)
+		if err > as.ProfileTolerance {
+			return nil
+		}
+
+		log.Printf("arrival scheduler: %s cannot reach %s at %.1fs without stopping", groupID, junctionID, start)
+	}
+
+	window := &CrossingWindow{
+		GroupID:       groupID,
+		JunctionID:    junctionID,
+		EdgeFrom:      leader.Edge,
+		Direction:     direction,
+		VehicleIDs:    make([]string, 0, len(group)),
+		Start:         start,
+		End:           start + occupancy,
+		CrossingSpeed: crossingSpeed,
+		Profile:       profile,
+	}
+
+	for _, v := range group {
+		window.VehicleIDs = append(window.VehicleIDs, v.ID)
+	}
+
+	as.Windows[groupID] = window
+
+	log.Printf("arrival scheduler: %s (%d vehicles, %s %s) assigned window %.1f-%.1fs at %s, cruise %.1f m/s",
+		groupID, len(group), leader.Edge, direction, window.Start, window.End, junctionID, profile.CruiseSpeed)
+
+	return window
+}
+
+func (tm *TrafficManager) isWindowStillFeasible(window *CrossingWindow, leader *models.Vehicle, now float64) bool {
+	if window.EdgeFrom != leader.Edge {
+		return false
+	}
+
+	if math.Abs(window.Profile.SpeedAt(now)-leader.Speed) <= tm.Arrivals.TrackingError {
+		return true
+	}
+
+	accel, decel := tm.profileLimits(tm.getVehicleType(leader))
+	distance := tm.estimateDistanceToIntersection(leader, nil)
+	profile, err := planSpeedProfile(now, distance, leader.Speed, window.CrossingSpeed, window.Start-now,
+		accel, decel, tm.Arrivals.MinCruiseSpeed, math.Max(tm.MaxPlatoonSpeed, window.CrossingSpeed))
+	if err > tm.Arrivals.ProfileTolerance {
+		return false
+	}
+
+	window.Profile = profile
+	return true
+}
+
+func (tm *TrafficManager) followCrossingWindow(window *CrossingWindow, leader *models.Vehicle, now float64) {
+	target := window.Profile.SpeedAt(now + tm.StepLength/2)
+	tm.Arrivals.profileLimits[leader.ID] = target
+
+	if leader.LeaderID == "" {
+		leader.DesiredSpeed = target
+	} else {
+		leader.DesiredSpeed = math.Min(leader.DesiredSpeed, target)
+	}
+}
+
+func (tm *TrafficManager) capToCrossingProfile(vehicle *models.Vehicle, speed float64) float64 {
+	if limit, exists := tm.Arrivals.profileLimits[vehicle.ID]; exists {
+		return math.Min(speed, limit)
+	}
+	return speed
+}
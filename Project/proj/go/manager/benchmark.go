@@ -29,6 +29,31 @@ type BenchmarkMetrics struct {
 	TrafficDensity        float64 `json:"trafficDensity"`
 	SimulationTimeElapsed float64 `json:"simulationTimeElapsed"`
 	CPUUsage              float64 `json:"cpuUsage"`
+	SpeedAmplification    float64 `json:"speedAmplification"`
+	GapAmplification      float64 `json:"gapAmplification"`
+	StringUnstable        int     `json:"stringUnstable"`
+	AccelLimitHits        int     `json:"accelLimitHits"`
+	DecelLimitHits        int     `json:"decelLimitHits"`
+	JerkLimitHits         int     `json:"jerkLimitHits"`
+	SafetyInterventions   int     `json:"safetyInterventions"`
+	PreemptionEvents      int     `json:"preemptionEvents"`
+	ActivePreemptions     int     `json:"activePreemptions"`
+	PersonDelay           float64 `json:"personDelay"`
+	TotalPersonDelay      float64 `json:"totalPersonDelay"`
+	MaxApproachWait       float64 `json:"maxApproachWait"`
+	FuelConsumptionMl     float64 `json:"fuelConsumptionMl"`
+	CO2EmissionsMg        float64 `json:"co2EmissionsMg"`
+	NOxEmissionsMg        float64 `json:"noxEmissionsMg"`
+	EcoAdvisories         int     `json:"ecoAdvisories"`
+
+	Connected    ConnectivityStats `json:"connected"`
+	NonConnected ConnectivityStats `json:"nonConnected"`
+
+	MessagesSent       int     `json:"messagesSent"`
+	MessagesLost       int     `json:"messagesLost"`
+	MessagesOutOfRange int     `json:"messagesOutOfRange"`
+	StaleVehicles      int     `json:"staleVehicles"`
+	TelemetryAge       float64 `json:"telemetryAge"`
 }
 
 type SimulationSummary struct {
@@ -47,6 +72,48 @@ type SimulationSummary struct {
 	MaxPlatoonSize           int     `json:"maxPlatoonSize"`
 	AverageTrafficDensity    float64 `json:"averageTrafficDensity"`
 	SimulationRuntime        float64 `json:"simulationRuntime"`
+	MaxSpeedAmplification    float64 `json:"maxSpeedAmplification"`
+	MaxGapAmplification      float64 `json:"maxGapAmplification"`
+	TotalAccelLimitHits      int     `json:"totalAccelLimitHits"`
+	TotalDecelLimitHits      int     `json:"totalDecelLimitHits"`
+	TotalJerkLimitHits       int     `json:"totalJerkLimitHits"`
+	LimitHitRate             float64 `json:"limitHitRate"`
+	SafetyInterventions      int     `json:"safetyInterventions"`
+	PreemptionEvents         int     `json:"preemptionEvents"`
+	AveragePreemptionTime    float64 `json:"averagePreemptionTime"`
+	PreemptionHeldVehicles   int     `json:"preemptionHeldVehicles"`
+	TotalPersonDelay         float64 `json:"totalPersonDelay"`
+	AveragePersonDelay       float64 `json:"averagePersonDelay"`
+	MaxApproachWait          float64 `json:"maxApproachWait"`
+	FairnessGrants           int     `json:"fairnessGrants"`
+	FairnessViolations       int     `json:"fairnessViolations"`
+	CrossingOrderOptimal     int     `json:"crossingOrderOptimal"`
+	CrossingOrderFallbacks   int     `json:"crossingOrderFallbacks"`
+	EcoDriving               bool    `json:"ecoDriving"`
+	TotalFuelConsumptionMl   float64 `json:"totalFuelConsumptionMl"`
+	TotalCO2EmissionsMg      float64 `json:"totalCO2EmissionsMg"`
+	TotalNOxEmissionsMg      float64 `json:"totalNOxEmissionsMg"`
+	FuelPerVehicleMl         float64 `json:"fuelPerVehicleMl"`
+	CO2PerVehicleMg          float64 `json:"co2PerVehicleMg"`
+	EcoAdvisories            int     `json:"ecoAdvisories"`
+	PenetrationRate          float64 `json:"penetrationRate"`
+	ConnectedVehicles        int     `json:"connectedVehicles"`
+	NonConnectedVehicles     int     `json:"nonConnectedVehicles"`
+	ConnectedAverageSpeed    float64 `json:"connectedAverageSpeed"`
+	NonConnectedAverageSpeed float64 `json:"nonConnectedAverageSpeed"`
+	ConnectedAverageWait     float64 `json:"connectedAverageWait"`
+	NonConnectedAverageWait  float64 `json:"nonConnectedAverageWait"`
+	ConnectedTravelTime      float64 `json:"connectedTravelTime"`
+	NonConnectedTravelTime   float64 `json:"nonConnectedTravelTime"`
+	V2XLatency               float64 `json:"v2xLatency"`
+	V2XLossRate              float64 `json:"v2xLossRate"`
+	V2XRange                 float64 `json:"v2xRange"`
+	MessagesSent             int     `json:"messagesSent"`
+	MessagesDelivered        int     `json:"messagesDelivered"`
+	MessagesLost             int     `json:"messagesLost"`
+	MessagesOutOfRange       int     `json:"messagesOutOfRange"`
+	DeliveryRatio            float64 `json:"deliveryRatio"`
+	AverageTelemetryAge      float64 `json:"averageTelemetryAge"`
 	Timestamp                string  `json:"timestamp"`
 }
 
@@ -59,6 +126,30 @@ func (tm *TrafficManager) StartBenchmark(duration int, name string) {
 	tm.ThroughputCounter = 0
 	tm.TotalCreatedVehicles = 0
 	tm.TotalRemovedVehicles = 0
+	tm.Shaper.TotalCommands = 0
+	tm.Shaper.TotalAccelLimits = 0
+	tm.Shaper.TotalDecelLimits = 0
+	tm.Shaper.TotalJerkLimits = 0
+	tm.Safety.TotalInterventions = 0
+	tm.Preemption.TotalEvents = 0
+	tm.Preemption.TotalHeld = 0
+	tm.Preemption.Events = make([]*PreemptionEvent, 0)
+	tm.Fairness.Grants = 0
+	tm.Fairness.Served = 0
+	tm.Fairness.Violations = 0
+	tm.CrossingOrder.Optimal = 0
+	tm.CrossingOrder.Fallbacks = 0
+	tm.CrossingOrder.Nodes = 0
+	tm.Emissions.TotalCO2Mg = 0
+	tm.Emissions.TotalNOxMg = 0
+	tm.Emissions.TotalFuelMl = 0
+	tm.EcoDriving.TotalAdvised = 0
+	tm.Connectivity.Connected = 0
+	tm.Connectivity.NonConnected = 0
+	tm.V2X.TotalSent = 0
+	tm.V2X.TotalDelivered = 0
+	tm.V2X.TotalLost = 0
+	tm.V2X.TotalOutOfRange = 0
 
 	os.MkdirAll("statistics", 0755)
 
@@ -73,6 +164,13 @@ func (tm *TrafficManager) RecordBenchmarkMetrics() {
 	maxWaitTime, minWaitTime := tm.calculateWaitTimeStats()
 	maxPlatoonSize := tm.calculateMaxPlatoonSize()
 	avgTravelTime, maxTravelTime := tm.calculateTravelTimeStats()
+	speedAmplification, gapAmplification, stringUnstable := tm.calculateStringStabilityStats()
+
+	personDelay := tm.calculatePersonDelay()
+	totalPersonDelay := personDelay
+	if len(tm.BenchmarkMetrics) > 0 {
+		totalPersonDelay += tm.BenchmarkMetrics[len(tm.BenchmarkMetrics)-1].TotalPersonDelay
+	}
 
 	metrics := BenchmarkMetrics{
 		TimeStep:              tm.TimeStep,
@@ -94,6 +192,29 @@ func (tm *TrafficManager) RecordBenchmarkMetrics() {
 		TrafficDensity:        tm.calculateTrafficDensity(),
 		SimulationTimeElapsed: time.Since(tm.BenchmarkStartTime).Seconds(),
 		CPUUsage:              calculateCPUUsage(),
+		SpeedAmplification:    speedAmplification,
+		GapAmplification:      gapAmplification,
+		StringUnstable:        stringUnstable,
+		AccelLimitHits:        tm.Shaper.StepAccelLimits,
+		DecelLimitHits:        tm.Shaper.StepDecelLimits,
+		JerkLimitHits:         tm.Shaper.StepJerkLimits,
+		SafetyInterventions:   tm.Safety.StepInterventions,
+		PreemptionEvents:      tm.Preemption.StepStarted,
+		ActivePreemptions:     len(tm.Preemption.Active),
+		PersonDelay:           personDelay,
+		TotalPersonDelay:      totalPersonDelay,
+		MaxApproachWait:       tm.calculateMaxApproachWait(),
+		FuelConsumptionMl:     tm.Emissions.StepFuelMl,
+		CO2EmissionsMg:        tm.Emissions.StepCO2Mg,
+		NOxEmissionsMg:        tm.Emissions.StepNOxMg,
+		EcoAdvisories:         tm.EcoDriving.StepAdvised,
+		Connected:             tm.calculateConnectivityStats(true),
+		NonConnected:          tm.calculateConnectivityStats(false),
+		MessagesSent:          tm.V2X.StepSent,
+		MessagesLost:          tm.V2X.StepLost,
+		MessagesOutOfRange:    tm.V2X.StepOutOfRange,
+		StaleVehicles:         tm.V2X.StaleVehicles,
+		TelemetryAge:          tm.V2X.AverageAge,
 	}
 
 	tm.BenchmarkMetrics = append(tm.BenchmarkMetrics, metrics)
@@ -293,6 +414,33 @@ func (tm *TrafficManager) SaveBenchmarkResults() {
 		"TrafficDensity",
 		"SimulationTimeElapsed",
 		"CPUUsage",
+		"SpeedAmplification",
+		"GapAmplification",
+		"StringUnstable",
+		"AccelLimitHits",
+		"DecelLimitHits",
+		"JerkLimitHits",
+		"SafetyInterventions",
+		"PreemptionEvents",
+		"ActivePreemptions",
+		"PersonDelay",
+		"TotalPersonDelay",
+		"MaxApproachWait",
+		"FuelConsumptionMl",
+		"CO2EmissionsMg",
+		"NOxEmissionsMg",
+		"EcoAdvisories",
+		"ConnectedVehicles",
+		"NonConnectedVehicles",
+		"ConnectedAverageSpeed",
+		"NonConnectedAverageSpeed",
+		"ConnectedAverageWaitTime",
+		"NonConnectedAverageWaitTime",
+		"MessagesSent",
+		"MessagesLost",
+		"MessagesOutOfRange",
+		"StaleVehicles",
+		"TelemetryAge",
 	}
 
 	if err := writer.Write(header); err != nil {
@@ -321,6 +469,33 @@ func (tm *TrafficManager) SaveBenchmarkResults() {
 			fmt.Sprintf("%.5f", m.TrafficDensity),
 			fmt.Sprintf("%.2f", m.SimulationTimeElapsed),
 			fmt.Sprintf("%.2f", m.CPUUsage),
+			fmt.Sprintf("%.3f", m.SpeedAmplification),
+			fmt.Sprintf("%.3f", m.GapAmplification),
+			fmt.Sprintf("%d", m.StringUnstable),
+			fmt.Sprintf("%d", m.AccelLimitHits),
+			fmt.Sprintf("%d", m.DecelLimitHits),
+			fmt.Sprintf("%d", m.JerkLimitHits),
+			fmt.Sprintf("%d", m.SafetyInterventions),
+			fmt.Sprintf("%d", m.PreemptionEvents),
+			fmt.Sprintf("%d", m.ActivePreemptions),
+			fmt.Sprintf("%.2f", m.PersonDelay),
+			fmt.Sprintf("%.2f", m.TotalPersonDelay),
+			fmt.Sprintf("%.1f", m.MaxApproachWait),
+			fmt.Sprintf("%.3f", m.FuelConsumptionMl),
+			fmt.Sprintf("%.1f", m.CO2EmissionsMg),
+			fmt.Sprintf("%.3f", m.NOxEmissionsMg),
+			fmt.Sprintf("%d", m.EcoAdvisories),
+			fmt.Sprintf("%d", m.Connected.Vehicles),
+			fmt.Sprintf("%d", m.NonConnected.Vehicles),
+			fmt.Sprintf("%.2f", m.Connected.AverageSpeed),
+			fmt.Sprintf("%.2f", m.NonConnected.AverageSpeed),
+			fmt.Sprintf("%.2f", m.Connected.AverageWaitTime),
+			fmt.Sprintf("%.2f", m.NonConnected.AverageWaitTime),
+			fmt.Sprintf("%d", m.MessagesSent),
+			fmt.Sprintf("%d", m.MessagesLost),
+			fmt.Sprintf("%d", m.MessagesOutOfRange),
+			fmt.Sprintf("%d", m.StaleVehicles),
+			fmt.Sprintf("%.2f", m.TelemetryAge),
 		}
 
 		if err := writer.Write(record); err != nil {
@@ -361,6 +536,10 @@ func (tm *TrafficManager) createSimulationSummary() SimulationSummary {
 	maxWaitTime := 0
 	maxTravelTime := 0.0
 	maxPlatoonSize := 0
+	maxSpeedAmplification := 0.0
+	maxGapAmplification := 0.0
+	maxApproachWait := 0.0
+	totalTelemetryAge := 0.0
 
 	for _, m := range tm.BenchmarkMetrics {
 		totalVehicles += m.TotalVehicles
@@ -369,6 +548,7 @@ func (tm *TrafficManager) createSimulationSummary() SimulationSummary {
 		totalQueue += m.IntersectionQueueSize
 		totalPlatoonSize += m.AveragePlatoonSize
 		totalTrafficDensity += m.TrafficDensity
+		totalTelemetryAge += m.TelemetryAge
 
 		if m.MaxWaitTime > maxWaitTime {
 			maxWaitTime = m.MaxWaitTime
@@ -379,8 +559,20 @@ func (tm *TrafficManager) createSimulationSummary() SimulationSummary {
 		if m.MaxPlatoonSize > maxPlatoonSize {
 			maxPlatoonSize = m.MaxPlatoonSize
 		}
+		if m.SpeedAmplification > maxSpeedAmplification {
+			maxSpeedAmplification = m.SpeedAmplification
+		}
+		if m.GapAmplification > maxGapAmplification {
+			maxGapAmplification = m.GapAmplification
+		}
+		if m.MaxApproachWait > maxApproachWait {
+			maxApproachWait = m.MaxApproachWait
+		}
 	}
 
+	connected := averageConnectivityStats(tm.BenchmarkMetrics, true)
+	nonConnected := averageConnectivityStats(tm.BenchmarkMetrics, false)
+
 	stepCount := len(tm.BenchmarkMetrics)
 	finalMetrics := tm.BenchmarkMetrics[len(tm.BenchmarkMetrics)-1]
 
@@ -400,6 +592,55 @@ func (tm *TrafficManager) createSimulationSummary() SimulationSummary {
 		MaxPlatoonSize:           maxPlatoonSize,
 		AverageTrafficDensity:    totalTrafficDensity / float64(stepCount),
 		SimulationRuntime:        finalMetrics.SimulationTimeElapsed,
+		MaxSpeedAmplification:    maxSpeedAmplification,
+		MaxGapAmplification:      maxGapAmplification,
+		TotalAccelLimitHits:      tm.Shaper.TotalAccelLimits,
+		TotalDecelLimitHits:      tm.Shaper.TotalDecelLimits,
+		TotalJerkLimitHits:       tm.Shaper.TotalJerkLimits,
+		LimitHitRate:             tm.calculateLimitHitRate(),
+		SafetyInterventions:      tm.Safety.TotalInterventions,
+		PreemptionEvents:         tm.Preemption.TotalEvents,
+		AveragePreemptionTime:    tm.calculateAveragePreemptionTime(),
+		PreemptionHeldVehicles:   tm.Preemption.TotalHeld,
+		TotalPersonDelay:         finalMetrics.TotalPersonDelay,
+		AveragePersonDelay:       finalMetrics.TotalPersonDelay / float64(stepCount),
+		MaxApproachWait:          maxApproachWait,
+		FairnessGrants:           tm.Fairness.Grants,
+		FairnessViolations:       tm.Fairness.Violations,
+		CrossingOrderOptimal:     tm.CrossingOrder.Optimal,
+		CrossingOrderFallbacks:   tm.CrossingOrder.Fallbacks,
+		EcoDriving:               tm.EcoDriving.Enabled,
+		TotalFuelConsumptionMl:   tm.Emissions.TotalFuelMl,
+		TotalCO2EmissionsMg:      tm.Emissions.TotalCO2Mg,
+		TotalNOxEmissionsMg:      tm.Emissions.TotalNOxMg,
+		FuelPerVehicleMl:         perVehicle(tm.Emissions.TotalFuelMl, tm.TotalCreatedVehicles),
+		CO2PerVehicleMg:          perVehicle(tm.Emissions.TotalCO2Mg, tm.TotalCreatedVehicles),
+		EcoAdvisories:            tm.EcoDriving.TotalAdvised,
+		PenetrationRate:          tm.Connectivity.PenetrationRate,
+		ConnectedVehicles:        tm.Connectivity.Connected,
+		NonConnectedVehicles:     tm.Connectivity.NonConnected,
+		ConnectedAverageSpeed:    connected.AverageSpeed,
+		NonConnectedAverageSpeed: nonConnected.AverageSpeed,
+		ConnectedAverageWait:     connected.AverageWaitTime,
+		NonConnectedAverageWait:  nonConnected.AverageWaitTime,
+		ConnectedTravelTime:      connected.AverageTravelTime,
+		NonConnectedTravelTime:   nonConnected.AverageTravelTime,
+		V2XLatency:               tm.V2X.Latency,
+		V2XLossRate:              tm.V2X.LossRate,
+		V2XRange:                 tm.V2X.Range,
+		MessagesSent:             tm.V2X.TotalSent,
+		MessagesDelivered:        tm.V2X.TotalDelivered,
+		MessagesLost:             tm.V2X.TotalLost,
+		MessagesOutOfRange:       tm.V2X.TotalOutOfRange,
+		DeliveryRatio:            tm.V2X.DeliveryRatio(),
+		AverageTelemetryAge:      totalTelemetryAge / float64(stepCount),
 		Timestamp:                time.Now().Format("2006-01-02T15:04:05"),
 	}
 }
+
+func perVehicle(total float64, vehicles int) float64 {
+	if vehicles == 0 {
+		return 0
+	}
+	return total / float64(vehicles)
+}
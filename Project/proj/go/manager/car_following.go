@@ -0,0 +1,136 @@
+package manager
+
+import (
+	"math"
+
+	"sumo/models"
+)
+
+func DefaultVehicleTypes() map[string]*models.VehicleType {
+	car := &models.VehicleType{
+		ID:              "car",
+		Class:           ClassCar,
+		Length:          5.0,
+		MaxAccel:        2.5,
+		ComfortDecel:    2.0,
+		MaxDecel:        4.5,
+		MaxJerk:         2.5,
+		MinGap:          2.0,
+		TimeHeadway:     1.5,
+		AccelExponent:   4.0,
+		CACCTimeGap:     0.9,
+		CACCGapGain:     0.25,
+		CACCSpeedGain:   0.6,
+		CACCFeedForward: 0.8,
+	}
+
+	truck := &models.VehicleType{
+		ID:              "truck",
+		Class:           ClassTruck,
+		Length:          12.0,
+		MaxAccel:        1.2,
+		ComfortDecel:    1.5,
+		MaxDecel:        4.0,
+		MaxJerk:         1.5,
+		MinGap:          3.0,
+		TimeHeadway:     1.8,
+		AccelExponent:   4.0,
+		CACCTimeGap:     1.2,
+		CACCGapGain:     0.2,
+		CACCSpeedGain:   0.5,
+		CACCFeedForward: 0.7,
+	}
+
+	bus := &models.VehicleType{
+		ID:              "bus",
+		Class:           ClassBus,
+		Length:          12.0,
+		MaxAccel:        1.2,
+		ComfortDecel:    1.5,
+		MaxDecel:        4.0,
+		MaxJerk:         1.5,
+		MinGap:          2.5,
+		TimeHeadway:     1.6,
+		AccelExponent:   4.0,
+		CACCTimeGap:     1.1,
+		CACCGapGain:     0.2,
+		CACCSpeedGain:   0.5,
+		CACCFeedForward: 0.7,
+	}
+
+	emergency := &models.VehicleType{
+		ID:              "emergency",
+		Class:           ClassEmergency,
+		Length:          6.5,
+		MaxAccel:        3.0,
+		ComfortDecel:    3.0,
+		MaxDecel:        6.0,
+		MaxJerk:         3.0,
+		MinGap:          2.5,
+		TimeHeadway:     1.2,
+		AccelExponent:   4.0,
+		CACCTimeGap:     0.9,
+		CACCGapGain:     0.25,
+		CACCSpeedGain:   0.6,
+		CACCFeedForward: 0.8,
+	}
+
+	return map[string]*models.VehicleType{
+		"car":             car,
+		"DEFAULT_VEHTYPE": car,
+		"truck":           truck,
+		"bus":             bus,
+		"emergency":       emergency,
+	}
+}
+
+func (tm *TrafficManager) getVehicleType(vehicle *models.Vehicle) *models.VehicleType {
+	if vt, exists := tm.VehicleTypes[vehicle.Type]; exists {
+		return vt
+	}
+	return tm.VehicleTypes["car"]
+}
+
+func (tm *TrafficManager) calculateBumperGap(follower, leader *models.Vehicle) float64 {
+	return leader.Pos - follower.Pos - tm.getVehicleType(leader).Length
+}
+
+func (tm *TrafficManager) idmAcceleration(vehicle, front *models.Vehicle, desiredSpeed, timeHeadway float64) float64 {
+	vt := tm.getVehicleType(vehicle)
+	speed := vehicle.Speed
+	freeRoad := 1 - math.Pow(speed/math.Max(desiredSpeed, 0.1), vt.AccelExponent)
+
+	if front == nil {
+		return vt.MaxAccel * freeRoad
+	}
+
+	gap := math.Max(tm.calculateBumperGap(vehicle, front), 0.1)
+	approachRate := speed - front.Speed
+	desiredGap := vt.MinGap + math.Max(0, speed*timeHeadway+
+		speed*approachRate/(2*math.Sqrt(vt.MaxAccel*vt.ComfortDecel)))
+
+	return vt.MaxAccel * (freeRoad - math.Pow(desiredGap/gap, 2))
+}
+
+func (tm *TrafficManager) caccAcceleration(vehicle, front *models.Vehicle, desiredSpeed float64) float64 {
+	vt := tm.getVehicleType(vehicle)
+
+	gapError := tm.calculateBumperGap(vehicle, front) - tm.calculateOptimalGap(vehicle, front)
+	accel := vt.CACCFeedForward*front.Acceleration +
+		vt.CACCSpeedGain*(front.Speed-vehicle.Speed) +
+		vt.CACCGapGain*gapError
+
+	safeAccel := tm.idmAcceleration(vehicle, front, desiredSpeed, tm.caccTimeGap(vehicle))
+	freeRoadAccel := tm.idmAcceleration(vehicle, nil, desiredSpeed, 0)
+
+	return math.Min(accel, math.Min(safeAccel, freeRoadAccel))
+}
+
+func (tm *TrafficManager) commandSpeed(vehicle *models.Vehicle, accel, maxSpeed float64) float64 {
+	vt := tm.getVehicleType(vehicle)
+
+	accel = math.Max(-vt.MaxDecel, math.Min(vt.MaxAccel, accel))
+	vehicle.CommandedAccel = accel
+
+	return math.Max(0.0, math.Min(maxSpeed, vehicle.Speed+accel*tm.StepLength))
+}
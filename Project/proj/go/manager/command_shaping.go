@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"math"
+	"sort"
+)
+
+type CommandShaper struct {
+	Enabled bool
+
+	StepCommands    int
+	StepAccelLimits int
+	StepDecelLimits int
+	StepJerkLimits  int
+
+	TotalCommands    int
+	TotalAccelLimits int
+	TotalDecelLimits int
+	TotalJerkLimits  int
+
+	lastAccel map[string]float64
+}
+
+func NewCommandShaper() *CommandShaper {
+	return &CommandShaper{
+		Enabled:   true,
+		lastAccel: make(map[string]float64),
+	}
+}
+
+func (tm *TrafficManager) ShapeCommands() {
+	cs := tm.Shaper
+	cs.StepCommands, cs.StepAccelLimits, cs.StepDecelLimits, cs.StepJerkLimits = 0, 0, 0, 0
+
+	for id := range cs.lastAccel {
+		if _, exists := tm.Vehicles[id]; !exists {
+			delete(cs.lastAccel, id)
+		}
+	}
+
+	if tm.Algorithm == AlgorithmSumo {
+		return
+	}
+
+	if !cs.Enabled {
+		for _, vehicle := range tm.Vehicles {
+			vehicle.CommandedSpeed = vehicle.DesiredSpeed
+		}
+		return
+	}
+
+	ids := make([]string, 0, len(tm.Vehicles))
+	for id := range tm.Vehicles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		vehicle := tm.Vehicles[id]
+		vt := tm.getVehicleType(vehicle)
+
+		accel := (vehicle.DesiredSpeed - vehicle.Speed) / tm.StepLength
+
+		switch {
+		case accel > vt.MaxAccel:
+			accel = vt.MaxAccel
+			cs.StepAccelLimits++
+		case accel < -vt.MaxDecel:
+			accel = -vt.MaxDecel
+			cs.StepDecelLimits++
+		}
+
+		_, overridden := tm.Safety.Overrides[id]
+		if previous, exists := cs.lastAccel[id]; exists && !overridden {
+			maxChange := vt.MaxJerk * tm.StepLength
+			if math.Abs(accel-previous) > maxChange {
+				accel = previous + math.Copysign(maxChange, accel-previous)
+				cs.StepJerkLimits++
+			}
+		}
+
+		shaped := math.Max(0.0, vehicle.Speed+accel*tm.StepLength)
+		cs.lastAccel[id] = (shaped - vehicle.Speed) / tm.StepLength
+
+		vehicle.CommandedSpeed = shaped
+		vehicle.CommandedAccel = cs.lastAccel[id]
+		cs.StepCommands++
+	}
+
+	cs.TotalCommands += cs.StepCommands
+	cs.TotalAccelLimits += cs.StepAccelLimits
+	cs.TotalDecelLimits += cs.StepDecelLimits
+	cs.TotalJerkLimits += cs.StepJerkLimits
+}
+
+func (tm *TrafficManager) calculateLimitHitRate() float64 {
+	cs := tm.Shaper
+	if cs.TotalCommands == 0 {
+		return 0
+	}
+
+	hits := cs.TotalAccelLimits + cs.TotalDecelLimits + cs.TotalJerkLimits
+	return float64(hits) / float64(cs.TotalCommands)
+}
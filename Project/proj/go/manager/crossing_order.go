@@ -0,0 +1,392 @@
+package manager
+
+import (
+	"log"
+	"math"
+
+	"sumo/models"
+)
+
+type CrossingOrderScheduler struct {
+	Horizon       float64
+	MaxNodes      int
+	MaxJobs       int
+	ClearanceTime float64
+	Headway       float64
+
+	Plans     map[string][]*CrossingWindow
+	Optimal   int
+	Fallbacks int
+	Nodes     int
+}
+
+type crossingJob struct {
+	id        string
+	movement  models.Movement
+	vehicles  []*models.Vehicle
+	approach  int
+	earliest  float64
+	discharge float64
+	occupancy float64
+	weight    float64
+	committed bool
+}
+
+type busyInterval struct {
+	movement models.Movement
+	end      float64
+}
+
+type crossingSearch struct {
+	tm       *TrafficManager
+	jobs     []*crossingJob
+	queues   [][]int
+	busy     []busyInterval
+	maxNodes int
+
+	nodes     int
+	exhausted bool
+	bestCost  float64
+	bestOrder []int
+	order     []int
+	starts    []float64
+}
+
+func NewCrossingOrderScheduler() *CrossingOrderScheduler {
+	return &CrossingOrderScheduler{
+		Horizon:       150.0,
+		MaxNodes:      200000,
+		MaxJobs:       12,
+		ClearanceTime: 1.5,
+		Headway:       1.0,
+		Plans:         make(map[string][]*CrossingWindow),
+	}
+}
+
+func (tm *TrafficManager) ScheduleCrossingOrder() {
+	cs := tm.CrossingOrder
+	now := tm.SimulationTime()
+	junctions := tm.getEdgeJunctions()
+
+	byJunction := make(map[string][][]*models.Vehicle)
+	for _, group := range tm.collectApproachGroups(cs.Horizon) {
+		junctionID := junctions[group[0].Edge]
+		byJunction[junctionID] = append(byJunction[junctionID], group)
+	}
+
+	cs.Plans = make(map[string][]*CrossingWindow)
+	tm.Arrivals.profileLimits = make(map[string]float64)
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		groups := byJunction[junctionID]
+		if len(groups) == 0 {
+			continue
+		}
+
+		jobs, queues := tm.buildCrossingJobs(groups, now)
+		busy := tm.collectJunctionBusyIntervals(junctionID, now)
+
+		search := &crossingSearch{
+			tm:       tm,
+			jobs:     jobs,
+			queues:   queues,
+			busy:     busy,
+			maxNodes: cs.MaxNodes,
+		}
+
+		order, starts, optimal := search.solve()
+		cs.Nodes += search.nodes
+		if optimal {
+			cs.Optimal++
+		} else {
+			cs.Fallbacks++
+			log.Printf("crossing order %s: search budget exhausted after %d nodes, using best order found for %d groups",
+				junctionID, search.nodes, len(jobs))
+		}
+
+		tm.applyCrossingPlan(junctionID, jobs, order, starts, now)
+	}
+}
+
+func (tm *TrafficManager) buildCrossingJobs(groups [][]*models.Vehicle, now float64) ([]*crossingJob, [][]int) {
+	cs := tm.CrossingOrder
+	jobs := make([]*crossingJob, 0, len(groups))
+	approaches := make(map[string]int)
+	queues := make([][]int, 0)
+
+	for _, group := range groups {
+		if len(jobs) >= cs.MaxJobs {
+			break
+		}
+
+		leader := group[0]
+		tail := group[len(group)-1]
+		accel, decel := tm.profileLimits(tm.getVehicleType(leader))
+
+		direction := tm.getVehicleDirection(leader)
+		path := tm.getMovementPath(leader.Edge, direction)
+		if path == nil {
+			continue
+		}
+
+		distance := tm.estimateDistanceToIntersection(leader, nil)
+		crossingSpeed := tm.getMovementSpeedLimit(direction)
+		maxSpeed := math.Max(math.Max(tm.MaxPlatoonSpeed, crossingSpeed), leader.Speed)
+		discharge := (leader.Pos - tail.Pos + tm.getVehicleType(tail).Length) / crossingSpeed
+
+		job := &crossingJob{
+			id:        tm.getApproachGroupID(leader),
+			movement:  models.Movement{Edge: leader.Edge, Direction: direction},
+			vehicles:  group,
+			earliest:  now + earliestArrivalDuration(distance, leader.Speed, crossingSpeed, accel, decel, maxSpeed),
+			discharge: discharge,
+			occupancy: pathLength(path)/crossingSpeed + discharge,
+			weight:    float64(len(group)),
+			committed: !leader.Connected || !tm.canStopComfortably(leader, distance),
+		}
+
+		index, exists := approaches[leader.Edge]
+		if !exists {
+			index = len(queues)
+			approaches[leader.Edge] = index
+			queues = append(queues, nil)
+		}
+
+		job.approach = index
+		queues[index] = append(queues[index], len(jobs))
+		jobs = append(jobs, job)
+	}
+
+	return jobs, queues
+}
+
+func (tm *TrafficManager) collectJunctionBusyIntervals(junctionID string, now float64) []busyInterval {
+	junctions := tm.getEdgeJunctions()
+	busy := make([]busyInterval, 0)
+
+	for _, vehicle := range tm.Vehicles {
+		movement, offset, internal := tm.getInternalEdgeMovement(vehicle.Edge)
+		if !internal || junctions[movement.Edge] != junctionID {
+			continue
+		}
+
+		path := tm.getMovementPath(movement.Edge, movement.Direction)
+		remaining := pathLength(path) + tm.getVehicleType(vehicle).Length - offset - vehicle.Pos
+		busy = append(busy, busyInterval{
+			movement: movement,
+			end:      now + math.Max(remaining, 0)/math.Max(vehicle.Speed, 1.0),
+		})
+	}
+
+	return busy
+}
+
+func (s *crossingSearch) conflicts(a, b models.Movement) bool {
+	return a.Edge != b.Edge && !s.tm.areMovementsCompatible(a.Edge, a.Direction, b.Edge, b.Direction)
+}
+
+func (s *crossingSearch) earliestStart(index int, order []int, starts []float64) float64 {
+	cs := s.tm.CrossingOrder
+	job := s.jobs[index]
+	start := job.earliest
+
+	for _, interval := range s.busy {
+		if s.conflicts(job.movement, interval.movement) {
+			start = math.Max(start, interval.end+cs.ClearanceTime)
+		}
+	}
+
+	for i, other := range order {
+		previous := s.jobs[other]
+		switch {
+		case previous.approach == job.approach:
+			start = math.Max(start, starts[i]+previous.discharge+cs.Headway)
+		case s.conflicts(job.movement, previous.movement):
+			start = math.Max(start, starts[i]+previous.occupancy+cs.ClearanceTime)
+		}
+	}
+
+	return start
+}
+
+func (s *crossingSearch) eligible(next []int) []int {
+	candidates := make([]int, 0, len(s.queues))
+	committed := make([]int, 0)
+
+	for approach, queue := range s.queues {
+		if next[approach] >= len(queue) {
+			continue
+		}
+
+		index := queue[next[approach]]
+		candidates = append(candidates, index)
+		if s.jobs[index].committed {
+			committed = append(committed, index)
+		}
+	}
+
+	if len(committed) > 0 {
+		return committed
+	}
+	return candidates
+}
+
+func (s *crossingSearch) greedy() ([]int, []float64, float64) {
+	next := make([]int, len(s.queues))
+	order := make([]int, 0, len(s.jobs))
+	starts := make([]float64, 0, len(s.jobs))
+	cost := 0.0
+
+	for len(order) < len(s.jobs) {
+		best, bestStart := -1, math.Inf(1)
+		for _, index := range s.eligible(next) {
+			start := s.earliestStart(index, order, starts)
+			if start < bestStart || (start == bestStart && s.jobs[index].weight > s.jobs[best].weight) {
+				best, bestStart = index, start
+			}
+		}
+
+		order = append(order, best)
+		starts = append(starts, bestStart)
+		cost += s.jobs[best].weight * (bestStart - s.jobs[best].earliest)
+		next[s.jobs[best].approach]++
+	}
+
+	return order, starts, cost
+}
+
+func (s *crossingSearch) solve() ([]int, []float64, bool) {
+	greedyOrder, _, greedyCost := s.greedy()
+
+	s.bestCost = greedyCost
+	s.bestOrder = append([]int(nil), greedyOrder...)
+	s.order = make([]int, 0, len(s.jobs))
+	s.starts = make([]float64, 0, len(s.jobs))
+
+	s.branch(make([]int, len(s.queues)), 0)
+
+	starts := make([]float64, 0, len(s.bestOrder))
+	for i, index := range s.bestOrder {
+		starts = append(starts, s.earliestStart(index, s.bestOrder[:i], starts))
+	}
+
+	return s.bestOrder, starts, !s.exhausted
+}
+
+func (s *crossingSearch) branch(next []int, cost float64) {
+	if s.exhausted {
+		return
+	}
+
+	s.nodes++
+	if s.nodes >= s.maxNodes {
+		s.exhausted = true
+		return
+	}
+
+	if len(s.order) == len(s.jobs) {
+		if cost < s.bestCost {
+			s.bestCost = cost
+			s.bestOrder = append(s.bestOrder[:0], s.order...)
+		}
+		return
+	}
+
+	if cost+s.lowerBound(next) >= s.bestCost {
+		return
+	}
+
+	for _, index := range s.eligible(next) {
+		job := s.jobs[index]
+		start := s.earliestStart(index, s.order, s.starts)
+
+		s.order = append(s.order, index)
+		s.starts = append(s.starts, start)
+		next[job.approach]++
+
+		s.branch(next, cost+job.weight*(start-job.earliest))
+
+		next[job.approach]--
+		s.order = s.order[:len(s.order)-1]
+		s.starts = s.starts[:len(s.starts)-1]
+	}
+}
+
+func (s *crossingSearch) lowerBound(next []int) float64 {
+	cs := s.tm.CrossingOrder
+	bound := 0.0
+
+	for approach, queue := range s.queues {
+		chain := math.Inf(-1)
+		for i := len(s.order) - 1; i >= 0; i-- {
+			if previous := s.jobs[s.order[i]]; previous.approach == approach {
+				chain = s.starts[i] + previous.discharge + cs.Headway
+				break
+			}
+		}
+
+		for _, index := range queue[next[approach]:] {
+			job := s.jobs[index]
+			start := math.Max(job.earliest, chain)
+
+			for i, other := range s.order {
+				if previous := s.jobs[other]; s.conflicts(job.movement, previous.movement) {
+					start = math.Max(start, s.starts[i]+previous.occupancy+cs.ClearanceTime)
+				}
+			}
+
+			bound += job.weight * (start - job.earliest)
+			chain = start + job.discharge + cs.Headway
+		}
+	}
+
+	return bound
+}
+
+func (tm *TrafficManager) applyCrossingPlan(junctionID string, jobs []*crossingJob, order []int, starts []float64, now float64) {
+	cs := tm.CrossingOrder
+	plan := make([]*CrossingWindow, 0, len(order))
+
+	for i, index := range order {
+		job := jobs[index]
+		leader := job.vehicles[0]
+		vt := tm.getVehicleType(leader)
+
+		distance := tm.estimateDistanceToIntersection(leader, nil)
+		crossingSpeed := tm.getMovementSpeedLimit(job.movement.Direction)
+		maxSpeed := math.Max(math.Max(tm.MaxPlatoonSpeed, crossingSpeed), leader.Speed)
+
+		accel, decel := tm.profileLimits(vt)
+		profile, err := planSpeedProfile(now, distance, leader.Speed, crossingSpeed, starts[i]-now,
+			accel, decel, 0, maxSpeed)
+
+		window := &CrossingWindow{
+			GroupID:       job.id,
+			JunctionID:    junctionID,
+			EdgeFrom:      job.movement.Edge,
+			Direction:     job.movement.Direction,
+			VehicleIDs:    tm.vehicleIDs(job.vehicles),
+			Start:         starts[i],
+			End:           starts[i] + job.occupancy,
+			CrossingSpeed: crossingSpeed,
+			Profile:       profile,
+		}
+		plan = append(plan, window)
+
+		switch {
+		case err <= tm.Arrivals.ProfileTolerance:
+			tm.followCrossingWindow(window, leader, now)
+		case starts[i] > job.earliest+tm.StepLength && !job.committed:
+			leader.DesiredSpeed = math.Min(leader.DesiredSpeed, tm.stopProfileSpeed(distance))
+		default:
+			accel := tm.idmAcceleration(leader, tm.FindVehicleAhead(leader), maxSpeed, vt.TimeHeadway)
+			leader.DesiredSpeed = tm.commandSpeed(leader, accel, maxSpeed)
+		}
+	}
+
+	cs.Plans[junctionID] = plan
+}
+
+func (tm *TrafficManager) GetCrossingPlans() map[string][]*CrossingWindow {
+	return tm.CrossingOrder.Plans
+}
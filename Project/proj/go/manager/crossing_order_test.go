@@ -0,0 +1,29 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func runCrossingOrder(seed int64) [][]*CrossingWindow {
+	tm := NewTrafficManager()
+	tm.SetAlgorithm(AlgorithmOptimal)
+	defer tm.Agents.Stop()
+
+	sim := NewJunctionSimulator(tm, UniformArrivals(seed, 300, 0.15))
+	plans := make([][]*CrossingWindow, 0)
+	for !sim.Done() && sim.Time < 400 {
+		sim.Step()
+		plans = append(plans, tm.CrossingOrder.Plans[":C2"])
+	}
+	return plans
+}
+
+func TestCrossingOrderIsReproducible(t *testing.T) {
+	first := runCrossingOrder(2)
+	second := runCrossingOrder(2)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Error("crossing orders differ between identical runs")
+	}
+}
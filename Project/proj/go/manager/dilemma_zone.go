@@ -0,0 +1,210 @@
+package manager
+
+import (
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"sumo/models"
+)
+
+const (
+	DilemmaClear = "clear"
+	DilemmaStop  = "stop"
+)
+
+type DilemmaZoneGuard struct {
+	AllRedInterval float64
+	MaxClearance   float64
+	ReactionTime   float64
+	ComfortDecel   float64
+	StopLineOffset float64
+	MovingSpeed    float64
+	MaxDecisions   int
+
+	Decisions []*DilemmaDecision
+	Cleared   int
+	Stopped   int
+	AllReds   int
+}
+
+type DilemmaDecision struct {
+	Time             float64 `json:"time"`
+	IntersectionID   string  `json:"intersection"`
+	VehicleID        string  `json:"vehicle"`
+	Edge             string  `json:"edge"`
+	Direction        string  `json:"direction"`
+	Distance         float64 `json:"distance"`
+	Speed            float64 `json:"speed"`
+	StoppingDistance float64 `json:"stopping_distance"`
+	FromEdge         string  `json:"from_edge"`
+	ToEdge           string  `json:"to_edge"`
+	Action           string  `json:"action"`
+}
+
+func NewDilemmaZoneGuard() *DilemmaZoneGuard {
+	return &DilemmaZoneGuard{
+		AllRedInterval: 2.0,
+		MaxClearance:   6.0,
+		ReactionTime:   1.0,
+		ComfortDecel:   3.0,
+		StopLineOffset: 1.0,
+		MovingSpeed:    0.5,
+		MaxDecisions:   200,
+		Decisions:      make([]*DilemmaDecision, 0),
+	}
+}
+
+func (g *DilemmaZoneGuard) forView() *DilemmaZoneGuard {
+	view := *g
+	view.Decisions = make([]*DilemmaDecision, 0)
+	view.Cleared, view.Stopped, view.AllReds = 0, 0, 0
+	return &view
+}
+
+func (tm *TrafficManager) getStopLineDistance(vehicle *models.Vehicle) (models.Movement, float64, bool) {
+	if movement, offset, internal := tm.getInternalEdgeMovement(vehicle.Edge); internal {
+		remaining := pathLength(tm.getMovementPath(movement.Edge, movement.Direction)) - offset - vehicle.Pos
+		return movement, -math.Max(remaining, 0), true
+	}
+
+	if _, isApproach := tm.getEdgeJunctions()[vehicle.Edge]; !isApproach {
+		return models.Movement{}, 0, false
+	}
+
+	movement := models.Movement{Edge: vehicle.Edge, Direction: tm.getVehicleDirection(vehicle)}
+	return movement, tm.estimateDistanceToIntersection(vehicle, nil), true
+}
+
+func (tm *TrafficManager) startPriorityClearance(intersectionID string, intersection *models.Intersection, now time.Time,
+	edge string, vehiclesByEdge map[string][]*models.Vehicle) bool {
+
+	g := tm.Dilemma
+	if intersection.PriorityEdge == "" || intersection.PriorityEdge == edge {
+		return false
+	}
+
+	from := intersection.PriorityEdge
+	decisions := make([]*DilemmaDecision, 0)
+	clearing := make([]string, 0)
+	clearance := 0.0
+
+	for _, otherEdge := range slices.Sorted(maps.Keys(vehiclesByEdge)) {
+		if otherEdge == edge {
+			continue
+		}
+
+		for _, vehicle := range vehiclesByEdge[otherEdge] {
+			if vehicle.Speed < g.MovingSpeed {
+				continue
+			}
+
+			movement, distance, ok := tm.getStopLineDistance(vehicle)
+			if !ok {
+				continue
+			}
+
+			stopping := vehicle.Speed*g.ReactionTime + vehicle.Speed*vehicle.Speed/(2*g.ComfortDecel)
+			decision := &DilemmaDecision{
+				Time:             tm.SimulationTime(),
+				IntersectionID:   intersectionID,
+				VehicleID:        vehicle.ID,
+				Edge:             movement.Edge,
+				Direction:        movement.Direction,
+				Distance:         distance,
+				Speed:            vehicle.Speed,
+				StoppingDistance: stopping,
+				FromEdge:         from,
+				ToEdge:           edge,
+				Action:           DilemmaStop,
+			}
+
+			if stopping > distance-g.StopLineOffset {
+				decision.Action = DilemmaClear
+				clearing = append(clearing, vehicle.ID)
+
+				length := math.Max(distance, 0) + pathLength(tm.getMovementPath(movement.Edge, movement.Direction)) +
+					tm.getVehicleType(vehicle).Length
+				clearance = math.Max(clearance, length/vehicle.Speed)
+			}
+
+			decisions = append(decisions, decision)
+		}
+	}
+
+	if len(decisions) == 0 {
+		return false
+	}
+
+	interval := g.AllRedInterval + math.Min(clearance, g.MaxClearance)
+	intersection.PriorityEdge = ""
+	intersection.ClearanceUntil = now.Add(time.Duration(interval * float64(time.Second)))
+	intersection.ClearingVehicles = clearing
+
+	for _, decision := range decisions {
+		tm.recordDilemmaDecision(decision)
+		log.Printf("dilemma zone: %s %s %.1fm from the stop line at %.1f m/s (stopping distance %.1fm) on switch from %s to %s",
+			decision.VehicleID, decision.Action, decision.Distance, decision.Speed, decision.StoppingDistance, from, edge)
+	}
+
+	g.AllReds++
+	log.Printf("dilemma zone: all-red at %s for %.1fs before switching priority from %s to %s, %d vehicles clearing",
+		intersectionID, interval, from, edge, len(clearing))
+
+	return true
+}
+
+func (tm *TrafficManager) holdDuringClearance(intersection *models.Intersection, vehiclesByEdge map[string][]*models.Vehicle) {
+	g := tm.Dilemma
+
+	for _, vehicles := range vehiclesByEdge {
+		for _, vehicle := range vehicles {
+			if slices.Contains(intersection.ClearingVehicles, vehicle.ID) {
+				continue
+			}
+
+			_, distance, ok := tm.getStopLineDistance(vehicle)
+			if !ok || distance < 0 {
+				continue
+			}
+
+			vehicle.DesiredSpeed = math.Min(vehicle.DesiredSpeed,
+				math.Sqrt(2*g.ComfortDecel*math.Max(distance-g.StopLineOffset, 0)))
+		}
+	}
+}
+
+func (tm *TrafficManager) endPriorityClearance(intersection *models.Intersection, now time.Time) {
+	if len(intersection.ClearingVehicles) > 0 && !now.Before(intersection.ClearanceUntil) {
+		intersection.ClearingVehicles = nil
+	}
+}
+
+func (tm *TrafficManager) holdForPriority(intersection *models.Intersection, vehicle *models.Vehicle) {
+	if slices.Contains(intersection.ClearingVehicles, vehicle.ID) {
+		return
+	}
+	vehicle.DesiredSpeed = 0.0
+}
+
+func (tm *TrafficManager) recordDilemmaDecision(decision *DilemmaDecision) {
+	g := tm.Dilemma
+
+	switch decision.Action {
+	case DilemmaClear:
+		g.Cleared++
+	case DilemmaStop:
+		g.Stopped++
+	}
+
+	g.Decisions = append(g.Decisions, decision)
+	if len(g.Decisions) > g.MaxDecisions {
+		g.Decisions = g.Decisions[len(g.Decisions)-g.MaxDecisions:]
+	}
+}
+
+func (tm *TrafficManager) GetDilemmaDecisions() []*DilemmaDecision {
+	return tm.Dilemma.Decisions
+}
@@ -0,0 +1,378 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type PreemptionController struct {
+	Enabled        bool
+	Types          map[string]bool
+	DetectionRange float64
+	MaxSpeed       float64
+
+	Active       map[string]*PreemptionEvent
+	Events       []*PreemptionEvent
+	ClearedLanes map[string]bool
+
+	StepStarted int
+	TotalEvents int
+	TotalHeld   int
+}
+
+type PreemptionEvent struct {
+	ID                    string
+	JunctionID            string
+	VehicleID             string
+	EdgeFrom              string
+	Direction             string
+	StartTime             float64
+	EndTime               float64
+	CancelledReservations int
+	RevokedPriorities     int
+	ReleasedGrants        int
+	BrokenPlatoons        int
+
+	held map[string]bool
+}
+
+func NewPreemptionController() *PreemptionController {
+	return &PreemptionController{
+		Enabled: true,
+		Types: map[string]bool{
+			"emergency":   true,
+			"ambulance":   true,
+			"firebrigade": true,
+			"police":      true,
+		},
+		DetectionRange: 150.0,
+		MaxSpeed:       19.4,
+		Active:         make(map[string]*PreemptionEvent),
+		Events:         make([]*PreemptionEvent, 0),
+		ClearedLanes:   make(map[string]bool),
+	}
+}
+
+func (tm *TrafficManager) isEmergencyVehicle(vehicle *models.Vehicle) bool {
+	return tm.Preemption.Types[vehicle.Type]
+}
+
+func (tm *TrafficManager) isPreemptionSuppressed(vehicle *models.Vehicle) bool {
+	return tm.isEmergencyVehicle(vehicle) || tm.Preemption.ClearedLanes[vehicle.Lane]
+}
+
+func (tm *TrafficManager) ManagePreemption() {
+	pc := tm.Preemption
+	pc.StepStarted = 0
+	pc.ClearedLanes = make(map[string]bool)
+
+	if !pc.Enabled {
+		return
+	}
+
+	now := tm.SimulationTime()
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		vehicle, movement := tm.findPreemptingVehicle(junctionID)
+
+		if event, exists := pc.Active[junctionID]; exists {
+			if vehicle != nil && vehicle.ID == event.VehicleID {
+				tm.clearEmergencyApproach(event, vehicle)
+				continue
+			}
+			tm.endPreemption(event, now)
+		}
+
+		if vehicle == nil {
+			continue
+		}
+
+		event := &PreemptionEvent{
+			ID:         fmt.Sprintf("pre_%s_%s_%d", junctionID, vehicle.ID, tm.TimeStep),
+			JunctionID: junctionID,
+			VehicleID:  vehicle.ID,
+			EdgeFrom:   movement.Edge,
+			Direction:  movement.Direction,
+			StartTime:  now,
+			held:       make(map[string]bool),
+		}
+
+		pc.Active[junctionID] = event
+		pc.StepStarted++
+		pc.TotalEvents++
+
+		log.Printf("preemption %s: started for %s (%s %s)", junctionID, vehicle.ID, movement.Edge, movement.Direction)
+		tm.clearEmergencyApproach(event, vehicle)
+	}
+}
+
+func (tm *TrafficManager) findPreemptingVehicle(junctionID string) (*models.Vehicle, models.Movement) {
+	pc := tm.Preemption
+	junctions := tm.getEdgeJunctions()
+
+	var nearest *models.Vehicle
+	var nearestMovement models.Movement
+	nearestDistance := math.Inf(1)
+
+	for _, vehicle := range tm.Vehicles {
+		if !tm.isEmergencyVehicle(vehicle) {
+			continue
+		}
+
+		var movement models.Movement
+		var distance float64
+
+		if internal, offset, isInternal := tm.getInternalEdgeMovement(vehicle.Edge); isInternal {
+			if junctions[internal.Edge] != junctionID {
+				continue
+			}
+			movement = internal
+			distance = -(offset + vehicle.Pos)
+		} else {
+			if junctions[vehicle.Edge] != junctionID {
+				continue
+			}
+
+			distance = tm.estimateDistanceToIntersection(vehicle, nil)
+			if distance < 0 || distance > pc.DetectionRange {
+				continue
+			}
+			movement = models.Movement{Edge: vehicle.Edge, Direction: tm.getVehicleDirection(vehicle)}
+		}
+
+		if distance < nearestDistance || (distance == nearestDistance && vehicle.ID < nearest.ID) {
+			nearest, nearestMovement, nearestDistance = vehicle, movement, distance
+		}
+	}
+
+	return nearest, nearestMovement
+}
+
+func (tm *TrafficManager) preemptionConflicts(event *PreemptionEvent, edge, direction string) bool {
+	if edge == event.EdgeFrom {
+		return false
+	}
+	return !tm.areMovementsCompatible(event.EdgeFrom, event.Direction, edge, direction)
+}
+
+func (tm *TrafficManager) conflictsWithPreemption(junctionID, edge, direction string) bool {
+	event, exists := tm.Preemption.Active[junctionID]
+	return exists && tm.preemptionConflicts(event, edge, direction)
+}
+
+func (tm *TrafficManager) isPriorityPreempted(junctionID string, platoon *models.Platoon) bool {
+	leader, exists := tm.Vehicles[platoon.LeaderID]
+	if !exists {
+		return false
+	}
+
+	if _, isApproach := tm.getEdgeJunctions()[leader.Edge]; !isApproach {
+		return false
+	}
+
+	if !tm.conflictsWithPreemption(junctionID, leader.Edge, tm.getVehicleDirection(leader)) {
+		return false
+	}
+
+	platoon.PriorityUntil = nil
+	return true
+}
+
+func (tm *TrafficManager) clearEmergencyApproach(event *PreemptionEvent, emergency *models.Vehicle) {
+	tm.revokeConflictingGrants(event)
+	tm.breakUpEmergencyLane(event, emergency)
+	tm.holdConflictingVehicles(event, emergency)
+	tm.applyEmergencySpeed(emergency)
+}
+
+func (tm *TrafficManager) revokeConflictingGrants(event *PreemptionEvent) {
+	junctions := tm.getEdgeJunctions()
+
+	for id, reservation := range tm.IntersectionReservations {
+		if reservation.IntersectionID != event.JunctionID ||
+			!tm.preemptionConflicts(event, reservation.EdgeFrom, reservation.Direction) {
+			continue
+		}
+
+		tm.cancelReservation(id, "preempted by "+event.VehicleID)
+		event.CancelledReservations++
+	}
+
+	for _, platoon := range tm.Platoons {
+		if platoon.PriorityUntil == nil || junctions[platoon.Edge] != event.JunctionID {
+			continue
+		}
+
+		leader, exists := tm.Vehicles[platoon.LeaderID]
+		if !exists || !tm.preemptionConflicts(event, leader.Edge, tm.getVehicleDirection(leader)) {
+			continue
+		}
+
+		platoon.PriorityUntil = nil
+		event.RevokedPriorities++
+		log.Printf("preemption %s: revoked priority of platoon %s for %s", event.JunctionID, platoon.ID, event.VehicleID)
+	}
+
+	for requesterID, grant := range tm.AIM.Grants {
+		if grant.JunctionID != event.JunctionID ||
+			!tm.preemptionConflicts(event, grant.EdgeFrom, grant.Direction) {
+			continue
+		}
+
+		tm.AIM.release(requesterID)
+		event.ReleasedGrants++
+		log.Printf("preemption %s: released aim grant of %s for %s", event.JunctionID, requesterID, event.VehicleID)
+	}
+
+	for groupID, window := range tm.Arrivals.Windows {
+		if window.JunctionID == event.JunctionID &&
+			tm.preemptionConflicts(event, window.EdgeFrom, window.Direction) {
+			delete(tm.Arrivals.Windows, groupID)
+		}
+	}
+}
+
+func (tm *TrafficManager) breakUpEmergencyLane(event *PreemptionEvent, emergency *models.Vehicle) {
+	pc := tm.Preemption
+	pc.ClearedLanes[emergency.Lane] = true
+
+	if platoonID, exists := tm.VehicleToPlatoon[emergency.ID]; exists {
+		tm.dissolvePlatoon(platoonID, "emergency vehicle "+emergency.ID+" is a member")
+		event.BrokenPlatoons++
+	}
+
+	platoonIDs := make([]string, 0)
+	for platoonID, platoon := range tm.Platoons {
+		if platoon.Lane != emergency.Lane {
+			continue
+		}
+
+		for _, vid := range platoon.VehicleIDs {
+			if vehicle, exists := tm.Vehicles[vid]; exists && vehicle.Pos > emergency.Pos &&
+				vehicle.Pos-emergency.Pos <= pc.DetectionRange {
+				platoonIDs = append(platoonIDs, platoonID)
+				break
+			}
+		}
+	}
+	sort.Strings(platoonIDs)
+
+	for _, platoonID := range platoonIDs {
+		tm.dissolvePlatoon(platoonID, "blocking emergency vehicle "+emergency.ID)
+		event.BrokenPlatoons++
+	}
+}
+
+func (tm *TrafficManager) dissolvePlatoon(platoonID, reason string) {
+	platoon, exists := tm.Platoons[platoonID]
+	if !exists {
+		return
+	}
+
+	if platoon.Maneuver != nil {
+		tm.abortManeuver(platoon.Maneuver, reason, tm.SimulationTime())
+	}
+
+	for _, vid := range platoon.VehicleIDs {
+		if maneuver := tm.findVehicleManeuver(vid); maneuver != nil {
+			tm.abortManeuver(maneuver, reason, tm.SimulationTime())
+		}
+
+		if vehicle, exists := tm.Vehicles[vid]; exists {
+			vehicle.PlatoonID = ""
+			vehicle.IsLeader = false
+		}
+		delete(tm.VehicleToPlatoon, vid)
+	}
+
+	delete(tm.Platoons, platoonID)
+	log.Printf("preemption: dissolved platoon %s, %s", platoonID, reason)
+}
+
+func (tm *TrafficManager) holdConflictingVehicles(event *PreemptionEvent, emergency *models.Vehicle) {
+	junctions := tm.getEdgeJunctions()
+
+	for id, vehicle := range tm.Vehicles {
+		if id == emergency.ID || junctions[vehicle.Edge] != event.JunctionID {
+			continue
+		}
+
+		distance := tm.estimateDistanceToIntersection(vehicle, nil)
+		if distance < 0 {
+			continue
+		}
+
+		direction := tm.getVehicleDirection(vehicle)
+
+		if vehicle.Edge == event.EdgeFrom {
+			if vehicle.Lane == emergency.Lane && vehicle.Pos > emergency.Pos {
+				limit := tm.getMovementSpeedLimit(direction)
+				accel := tm.idmAcceleration(vehicle, tm.FindVehicleAhead(vehicle), limit, tm.getVehicleType(vehicle).TimeHeadway)
+				vehicle.DesiredSpeed = tm.commandSpeed(vehicle, accel, limit)
+			}
+			continue
+		}
+
+		if !tm.preemptionConflicts(event, vehicle.Edge, direction) {
+			continue
+		}
+
+		vt := tm.getVehicleType(vehicle)
+		if vehicle.Speed*vehicle.Speed/(2*vt.MaxDecel) > distance-tm.Signals.StopLineOffset {
+			continue
+		}
+
+		vehicle.DesiredSpeed = math.Min(vehicle.DesiredSpeed, tm.stopProfileSpeed(distance))
+
+		if !event.held[id] {
+			event.held[id] = true
+			tm.Preemption.TotalHeld++
+		}
+	}
+}
+
+func (tm *TrafficManager) applyEmergencySpeed(emergency *models.Vehicle) {
+	target := tm.Preemption.MaxSpeed
+	direction := tm.getVehicleDirection(emergency)
+	turnSpeed := tm.getMovementSpeedLimit(direction)
+
+	if _, _, internal := tm.getInternalEdgeMovement(emergency.Edge); internal {
+		target = turnSpeed
+	} else if distance := tm.estimateDistanceToIntersection(emergency, nil); distance >= 0 {
+		vt := tm.getVehicleType(emergency)
+		target = math.Min(target, math.Sqrt(turnSpeed*turnSpeed+2*vt.ComfortDecel*distance))
+	}
+
+	accel := tm.idmAcceleration(emergency, tm.FindVehicleAhead(emergency), target, tm.getVehicleType(emergency).TimeHeadway)
+	emergency.DesiredSpeed = tm.commandSpeed(emergency, accel, target)
+}
+
+func (tm *TrafficManager) endPreemption(event *PreemptionEvent, now float64) {
+	pc := tm.Preemption
+	event.EndTime = now
+
+	log.Printf("preemption %s: ended for %s after %.1fs, held %d vehicles, cancelled %d reservations, revoked %d priorities, released %d grants, broke %d platoons",
+		event.JunctionID, event.VehicleID, event.EndTime-event.StartTime, len(event.held),
+		event.CancelledReservations, event.RevokedPriorities, event.ReleasedGrants, event.BrokenPlatoons)
+
+	pc.Events = append(pc.Events, event)
+	delete(pc.Active, event.JunctionID)
+}
+
+func (tm *TrafficManager) calculateAveragePreemptionTime() float64 {
+	events := tm.Preemption.Events
+	if len(events) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, event := range events {
+		total += event.EndTime - event.StartTime
+	}
+
+	return total / float64(len(events))
+}
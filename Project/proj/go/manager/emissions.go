@@ -0,0 +1,199 @@
+package manager
+
+import (
+	"math"
+
+	"sumo/models"
+)
+
+type EmissionCoefficients struct {
+	C0 float64
+	C1 float64
+	C2 float64
+	C3 float64
+	C4 float64
+	C5 float64
+}
+
+type EmissionProfile struct {
+	ID             string
+	CO2MgPerSecond EmissionCoefficients
+	NOxMgPerSecond EmissionCoefficients
+	CO2PerFuelMl   float64
+}
+
+type VehicleEmissions struct {
+	CO2Mg  float64
+	NOxMg  float64
+	FuelMl float64
+}
+
+type EmissionModel struct {
+	Profiles map[string]*EmissionProfile
+
+	Vehicles    map[string]*VehicleEmissions
+	StepCO2Mg   float64
+	StepNOxMg   float64
+	StepFuelMl  float64
+	TotalCO2Mg  float64
+	TotalNOxMg  float64
+	TotalFuelMl float64
+}
+
+type EcoDriver struct {
+	Enabled    bool
+	Accel      float64
+	Decel      float64
+	QueueSpeed float64
+
+	StepAdvised  int
+	TotalAdvised int
+}
+
+func NewEmissionModel() *EmissionModel {
+	return &EmissionModel{
+		Profiles: DefaultEmissionProfiles(),
+		Vehicles: make(map[string]*VehicleEmissions),
+	}
+}
+
+func NewEcoDriver() *EcoDriver {
+	return &EcoDriver{
+		Enabled:    false,
+		Accel:      1.0,
+		Decel:      1.5,
+		QueueSpeed: 0.5,
+	}
+}
+
+func DefaultEmissionProfiles() map[string]*EmissionProfile {
+	gasolineCO2PerMl := 2392.0
+	dieselCO2PerMl := 2640.0
+
+	return map[string]*EmissionProfile{
+		ClassCar: {
+			ID:             "car_gasoline",
+			CO2MgPerSecond: EmissionCoefficients{C0: 520, C1: 230, C2: 20, C3: 60, C4: 0.5, C5: 0.05},
+			NOxMgPerSecond: EmissionCoefficients{C0: 0.1, C1: 0.3, C2: 0.05, C3: 0.03, C4: 0.001, C5: 0.0001},
+			CO2PerFuelMl:   gasolineCO2PerMl,
+		},
+		ClassTaxi: {
+			ID:             "taxi_diesel",
+			CO2MgPerSecond: EmissionCoefficients{C0: 480, C1: 210, C2: 18, C3: 55, C4: 0.45, C5: 0.045},
+			NOxMgPerSecond: EmissionCoefficients{C0: 0.8, C1: 2.5, C2: 0.4, C3: 0.25, C4: 0.01, C5: 0.001},
+			CO2PerFuelMl:   dieselCO2PerMl,
+		},
+		ClassEmergency: {
+			ID:             "van_diesel",
+			CO2MgPerSecond: EmissionCoefficients{C0: 750, C1: 330, C2: 28, C3: 85, C4: 0.7, C5: 0.07},
+			NOxMgPerSecond: EmissionCoefficients{C0: 1.2, C1: 3.5, C2: 0.6, C3: 0.35, C4: 0.015, C5: 0.0015},
+			CO2PerFuelMl:   dieselCO2PerMl,
+		},
+		ClassBus: {
+			ID:             "bus_diesel",
+			CO2MgPerSecond: EmissionCoefficients{C0: 2100, C1: 1250, C2: 90, C3: 260, C4: 2.0, C5: 0.12},
+			NOxMgPerSecond: EmissionCoefficients{C0: 12, C1: 30, C2: 4, C3: 3, C4: 0.1, C5: 0.006},
+			CO2PerFuelMl:   dieselCO2PerMl,
+		},
+		ClassTruck: {
+			ID:             "truck_diesel",
+			CO2MgPerSecond: EmissionCoefficients{C0: 2300, C1: 1400, C2: 100, C3: 280, C4: 2.2, C5: 0.14},
+			NOxMgPerSecond: EmissionCoefficients{C0: 14, C1: 34, C2: 4.5, C3: 3.4, C4: 0.12, C5: 0.007},
+			CO2PerFuelMl:   dieselCO2PerMl,
+		},
+	}
+}
+
+func (c EmissionCoefficients) rate(speed, accel float64) float64 {
+	rate := c.C0 + c.C1*speed*accel + c.C2*speed*accel*accel +
+		c.C3*speed + c.C4*speed*speed + c.C5*speed*speed*speed
+	return math.Max(rate, 0)
+}
+
+func (tm *TrafficManager) getEmissionProfile(vehicle *models.Vehicle) *EmissionProfile {
+	em := tm.Emissions
+	if profile, exists := em.Profiles[tm.getVehicleClass(vehicle).ID]; exists {
+		return profile
+	}
+	return em.Profiles[ClassCar]
+}
+
+func (tm *TrafficManager) ComputeEmissions() {
+	em := tm.Emissions
+	em.StepCO2Mg, em.StepNOxMg, em.StepFuelMl = 0, 0, 0
+
+	for id := range em.Vehicles {
+		if _, exists := tm.Vehicles[id]; !exists {
+			delete(em.Vehicles, id)
+		}
+	}
+
+	for id, vehicle := range tm.Vehicles {
+		profile := tm.getEmissionProfile(vehicle)
+
+		co2 := profile.CO2MgPerSecond.rate(vehicle.Speed, vehicle.Acceleration) * tm.StepLength
+		nox := profile.NOxMgPerSecond.rate(vehicle.Speed, vehicle.Acceleration) * tm.StepLength
+		fuel := co2 / profile.CO2PerFuelMl
+
+		record, exists := em.Vehicles[id]
+		if !exists {
+			record = &VehicleEmissions{}
+			em.Vehicles[id] = record
+		}
+		record.CO2Mg += co2
+		record.NOxMg += nox
+		record.FuelMl += fuel
+
+		em.StepCO2Mg += co2
+		em.StepNOxMg += nox
+		em.StepFuelMl += fuel
+	}
+
+	em.TotalCO2Mg += em.StepCO2Mg
+	em.TotalNOxMg += em.StepNOxMg
+	em.TotalFuelMl += em.StepFuelMl
+}
+
+func (tm *TrafficManager) profileLimits(vt *models.VehicleType) (float64, float64) {
+	eco := tm.EcoDriving
+	if !eco.Enabled {
+		return vt.MaxAccel, vt.ComfortDecel
+	}
+	return math.Min(vt.MaxAccel, eco.Accel), math.Min(vt.ComfortDecel, eco.Decel)
+}
+
+func (tm *TrafficManager) ApplyEcoDriving() {
+	eco := tm.EcoDriving
+	eco.StepAdvised = 0
+
+	if !eco.Enabled {
+		return
+	}
+
+	junctions := tm.getEdgeJunctions()
+
+	for _, vehicle := range tm.Vehicles {
+		if _, isApproach := junctions[vehicle.Edge]; !isApproach || tm.isEmergencyVehicle(vehicle) {
+			continue
+		}
+
+		target := vehicle.DesiredSpeed
+
+		if front := tm.FindVehicleAhead(vehicle); front != nil && front.Edge == vehicle.Edge && front.Speed < eco.QueueSpeed {
+			vt := tm.getVehicleType(vehicle)
+			gap := front.Pos - tm.getVehicleType(front).Length - vt.MinGap - vehicle.Pos
+			target = math.Min(target, math.Sqrt(2*eco.Decel*math.Max(gap, 0)))
+		}
+
+		if vehicle.PlatoonID == "" || vehicle.IsLeader {
+			target = math.Min(target, vehicle.Speed+eco.Accel*tm.StepLength)
+		}
+
+		if target < vehicle.DesiredSpeed {
+			vehicle.DesiredSpeed = target
+			eco.StepAdvised++
+		}
+	}
+
+	eco.TotalAdvised += eco.StepAdvised
+}
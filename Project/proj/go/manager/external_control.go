@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+
+	"sumo/models"
+)
+
+type ExternalPhaseController struct {
+	MinPhaseTime float64
+
+	Requested map[string]int
+	Switches  int
+}
+
+func NewExternalPhaseController() *ExternalPhaseController {
+	return &ExternalPhaseController{
+		MinPhaseTime: 4.0,
+		Requested:    make(map[string]int),
+	}
+}
+
+func (tm *TrafficManager) GetExternalPhases(junctionID string) []models.SignalPhase {
+	return tm.getMaxPressurePhases(junctionID)
+}
+
+func (tm *TrafficManager) RequestExternalPhase(junctionID string, phase int) error {
+	phases := tm.GetExternalPhases(junctionID)
+	if len(phases) == 0 {
+		return fmt.Errorf("unknown junction %q", junctionID)
+	}
+	if phase < 0 || phase >= len(phases) {
+		return fmt.Errorf("phase %d out of range for junction %s (%d phases)", phase, junctionID, len(phases))
+	}
+
+	tm.External.Requested[junctionID] = phase
+	return nil
+}
+
+func (tm *TrafficManager) ManageExternalPhases() {
+	ec := tm.External
+	sc := tm.Signals
+	now := tm.SimulationTime()
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		phases := tm.GetExternalPhases(junctionID)
+		if len(phases) == 0 {
+			continue
+		}
+
+		intersection := tm.getOrCreateIntersection(junctionID)
+		if intersection.CurrentControlState == nil {
+			intersection.CurrentControlState = &models.IntersectionControlState{
+				CurrentPhase:      ec.Requested[junctionID],
+				SignalInterval:    models.SignalGreen,
+				IntervalStartTime: now,
+				LastDetectionTime: now,
+			}
+		}
+
+		state := intersection.CurrentControlState
+		elapsed := now - state.IntervalStartTime
+
+		switch state.SignalInterval {
+		case models.SignalGreen:
+			requested := ec.Requested[junctionID]
+			if requested == state.CurrentPhase || elapsed < ec.MinPhaseTime {
+				break
+			}
+
+			log.Printf("external %s: switching from phase %s to %s",
+				junctionID, phases[state.CurrentPhase].Name, phases[requested].Name)
+
+			state.NextPhase = requested
+			ec.Switches++
+			tm.setSignalInterval(intersection, models.SignalYellow, now)
+
+		case models.SignalYellow:
+			if elapsed >= sc.YellowTime {
+				tm.setSignalInterval(intersection, models.SignalAllRed, now)
+			}
+
+		case models.SignalAllRed:
+			if elapsed >= sc.AllRedTime {
+				state.CurrentPhase = state.NextPhase
+				state.LastDetectionTime = now
+				tm.setSignalInterval(intersection, models.SignalGreen, now)
+			}
+		}
+
+		tm.applySignalCommands(junctionID, phases[state.CurrentPhase], state.SignalInterval)
+	}
+}
@@ -0,0 +1,262 @@
+package manager
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type FairnessGuard struct {
+	Enabled      bool
+	MaxWait      float64
+	MovementWait map[models.Movement]float64
+	DemandRange  float64
+	ServiceLead  float64
+
+	Heads      map[string]*approachHead
+	Granted    map[string]bool
+	MaxWaits   map[models.Movement]float64
+	Grants     int
+	Served     int
+	Violations int
+}
+
+type approachHead struct {
+	vehicleID string
+	movement  models.Movement
+	since     float64
+	granted   bool
+}
+
+func NewFairnessGuard() *FairnessGuard {
+	return &FairnessGuard{
+		Enabled:      true,
+		MaxWait:      60.0,
+		MovementWait: make(map[models.Movement]float64),
+		DemandRange:  50.0,
+		ServiceLead:  20.0,
+		Heads:        make(map[string]*approachHead),
+		Granted:      make(map[string]bool),
+		MaxWaits:     make(map[models.Movement]float64),
+	}
+}
+
+func (fg *FairnessGuard) maxWaitFor(movement models.Movement) float64 {
+	if wait, exists := fg.MovementWait[movement]; exists {
+		return wait
+	}
+	return fg.MaxWait
+}
+
+func (tm *TrafficManager) EnforceBoundedWait() {
+	fg := tm.Fairness
+	now := tm.SimulationTime()
+
+	tm.updateApproachHeads(now)
+
+	if !fg.Enabled {
+		return
+	}
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		granted := tm.selectOverdueApproaches(junctionID, now)
+		if len(granted) == 0 {
+			continue
+		}
+
+		tm.serveOverdueApproaches(junctionID, granted)
+	}
+}
+
+func (tm *TrafficManager) updateApproachHeads(now float64) {
+	fg := tm.Fairness
+	heads := make(map[string]*models.Vehicle)
+
+	for _, approach := range tm.getApproachEdges() {
+		var head *models.Vehicle
+		for _, vehicle := range tm.Vehicles {
+			if vehicle.Edge == approach && (head == nil || vehicle.Pos > head.Pos) {
+				head = vehicle
+			}
+		}
+
+		if head != nil {
+			distance := tm.estimateDistanceToIntersection(head, nil)
+			if distance >= 0 && distance <= fg.DemandRange {
+				heads[approach] = head
+			}
+		}
+	}
+
+	for approach, record := range fg.Heads {
+		head, exists := heads[approach]
+		if exists && head.ID == record.vehicleID {
+			continue
+		}
+
+		tm.closeApproachHead(approach, record, now)
+		delete(fg.Heads, approach)
+	}
+
+	for approach, head := range heads {
+		if _, exists := fg.Heads[approach]; exists {
+			continue
+		}
+
+		fg.Heads[approach] = &approachHead{
+			vehicleID: head.ID,
+			movement:  models.Movement{Edge: approach, Direction: tm.getVehicleDirection(head)},
+			since:     now,
+		}
+	}
+}
+
+func (tm *TrafficManager) closeApproachHead(approach string, record *approachHead, now float64) {
+	fg := tm.Fairness
+	vehicle, exists := tm.Vehicles[record.vehicleID]
+	if !exists || vehicle.Edge == approach {
+		return
+	}
+
+	wait := now - record.since
+	fg.Served++
+	fg.MaxWaits[record.movement] = math.Max(fg.MaxWaits[record.movement], wait)
+
+	if wait > fg.maxWaitFor(record.movement) {
+		fg.Violations++
+		log.Printf("fairness: %s (%s %s) waited %.1fs, above the %.1fs bound",
+			record.vehicleID, record.movement.Edge, record.movement.Direction, wait, fg.maxWaitFor(record.movement))
+	} else if record.granted {
+		log.Printf("fairness: %s (%s %s) served after %.1fs", record.vehicleID,
+			record.movement.Edge, record.movement.Direction, wait)
+	}
+}
+
+func (tm *TrafficManager) selectOverdueApproaches(junctionID string, now float64) []*approachHead {
+	fg := tm.Fairness
+	junctions := tm.getEdgeJunctions()
+
+	overdue := make([]*approachHead, 0)
+	for approach, record := range fg.Heads {
+		if junctions[approach] != junctionID {
+			continue
+		}
+
+		if record.granted || now-record.since >= fg.maxWaitFor(record.movement)-fg.ServiceLead {
+			overdue = append(overdue, record)
+		}
+	}
+
+	sort.Slice(overdue, func(i, j int) bool {
+		if overdue[i].granted != overdue[j].granted {
+			return overdue[i].granted
+		}
+		if overdue[i].since != overdue[j].since {
+			return overdue[i].since < overdue[j].since
+		}
+		return overdue[i].movement.Edge < overdue[j].movement.Edge
+	})
+
+	granted := make([]*approachHead, 0, len(overdue))
+	for _, record := range overdue {
+		compatible := true
+		for _, other := range granted {
+			if !tm.areMovementsCompatible(record.movement.Edge, record.movement.Direction,
+				other.movement.Edge, other.movement.Direction) {
+				compatible = false
+				break
+			}
+		}
+
+		if !compatible {
+			continue
+		}
+
+		if !record.granted {
+			record.granted = true
+			fg.Grants++
+			log.Printf("fairness %s: %s (%s %s) waited %.1fs, holding conflicting movements",
+				junctionID, record.vehicleID, record.movement.Edge, record.movement.Direction, now-record.since)
+		}
+		granted = append(granted, record)
+	}
+
+	return granted
+}
+
+func (tm *TrafficManager) serveOverdueApproaches(junctionID string, granted []*approachHead) {
+	junctions := tm.getEdgeJunctions()
+	heads := make(map[string]bool, len(granted))
+
+	for _, record := range granted {
+		heads[record.vehicleID] = true
+
+		if vehicle, exists := tm.Vehicles[record.vehicleID]; exists {
+			limit := tm.getMovementSpeedLimit(record.movement.Direction)
+			accel := tm.idmAcceleration(vehicle, tm.FindVehicleAhead(vehicle), limit, tm.getVehicleType(vehicle).TimeHeadway)
+			vehicle.DesiredSpeed = math.Max(vehicle.DesiredSpeed, tm.commandSpeed(vehicle, accel, limit))
+		}
+	}
+
+	for _, platoon := range tm.Platoons {
+		leader, exists := tm.Vehicles[platoon.LeaderID]
+		if !exists || platoon.PriorityUntil == nil || junctions[leader.Edge] != junctionID {
+			continue
+		}
+
+		if tm.conflictsWithGranted(leader, granted) {
+			platoon.PriorityUntil = nil
+		}
+	}
+
+	for id, vehicle := range tm.Vehicles {
+		if heads[id] || junctions[vehicle.Edge] != junctionID || !tm.conflictsWithGranted(vehicle, granted) {
+			continue
+		}
+
+		distance := tm.estimateDistanceToIntersection(vehicle, nil)
+		if distance < 0 || !tm.canStopComfortably(vehicle, distance) {
+			continue
+		}
+
+		vehicle.DesiredSpeed = math.Min(vehicle.DesiredSpeed, tm.stopProfileSpeed(distance))
+	}
+}
+
+func (tm *TrafficManager) conflictsWithGranted(vehicle *models.Vehicle, granted []*approachHead) bool {
+	direction := tm.getVehicleDirection(vehicle)
+
+	for _, record := range granted {
+		if vehicle.Edge == record.movement.Edge {
+			continue
+		}
+
+		if !tm.areMovementsCompatible(record.movement.Edge, record.movement.Direction, vehicle.Edge, direction) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (tm *TrafficManager) getApproachEdges() []string {
+	edges := make([]string, 0)
+	for edge := range tm.getEdgeJunctions() {
+		edges = append(edges, edge)
+	}
+	sort.Strings(edges)
+	return edges
+}
+
+func (tm *TrafficManager) calculateMaxApproachWait() float64 {
+	now := tm.SimulationTime()
+	wait := 0.0
+
+	for _, record := range tm.Fairness.Heads {
+		wait = math.Max(wait, now-record.since)
+	}
+
+	return wait
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"sumo/models"
+)
+
+type boundedWaitReport struct {
+	MaxWaits   map[models.Movement]float64
+	Served     int
+	Violations []string
+}
+
+func runBoundedWaitScenario(seed int64, bound, until float64) *boundedWaitReport {
+	tm := NewTrafficManager()
+	tm.SetAlgorithm(AlgorithmCustom)
+	tm.Fairness.MaxWait = bound
+	defer tm.Agents.Stop()
+
+	sim := NewJunctionSimulator(tm, AdversarialArrivals(seed, 600))
+	return checkBoundedWait(sim, bound, until)
+}
+
+func checkBoundedWait(sim *JunctionSimulator, bound, until float64) *boundedWaitReport {
+	report := &boundedWaitReport{MaxWaits: make(map[models.Movement]float64)}
+
+	heads := make(map[string]*SimulatedVehicle)
+	since := make(map[string]float64)
+
+	sim.OnJunction = func(vehicle *SimulatedVehicle) {
+		approach := vehicle.Arrival.EdgeFrom
+		if heads[approach] != vehicle {
+			return
+		}
+
+		wait := sim.Time - since[approach]
+		movement := vehicle.Movement()
+		report.Served++
+		report.MaxWaits[movement] = math.Max(report.MaxWaits[movement], wait)
+
+		if wait > bound+sim.Manager.StepLength {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s (%s %s) waited %.1fs at %.0fs",
+				vehicle.Arrival.ID, movement.Edge, movement.Direction, wait, sim.Time))
+		}
+		delete(heads, approach)
+	}
+
+	for sim.Time < until && !sim.Done() {
+		sim.Step()
+
+		for _, approach := range sim.Manager.getApproachEdges() {
+			var head *SimulatedVehicle
+			for _, vehicle := range sim.Vehicles {
+				if vehicle.OnApproach() && vehicle.Arrival.EdgeFrom == approach && (head == nil || vehicle.Pos > head.Pos) {
+					head = vehicle
+				}
+			}
+
+			if head == nil || sim.edgeLength(approach)-head.Pos > sim.Manager.Fairness.DemandRange {
+				delete(heads, approach)
+				continue
+			}
+
+			if heads[approach] != head {
+				heads[approach] = head
+				since[approach] = sim.Time
+			}
+		}
+	}
+
+	for approach, head := range heads {
+		wait := sim.Time - since[approach]
+		if wait > bound+sim.Manager.StepLength {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s (%s %s) still waiting after %.1fs",
+				head.Arrival.ID, approach, head.Arrival.Direction, wait))
+		}
+	}
+
+	return report
+}
+
+func TestBoundedWaitUnderAdversarialArrivals(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		report := runBoundedWaitScenario(seed, 60, 700)
+		if len(report.Violations) > 0 {
+			t.Errorf("seed %d: %d approaches waited longer than 60s: %v",
+				seed, len(report.Violations), report.Violations)
+		}
+		if report.Served == 0 {
+			t.Errorf("seed %d: no approach head was served", seed)
+		}
+	}
+}
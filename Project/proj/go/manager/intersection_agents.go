@@ -0,0 +1,501 @@
+package manager
+
+import (
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"sumo/models"
+)
+
+const AgentInboxSize = 256
+
+type AgentCoordinator struct {
+	Agents   map[string]*IntersectionAgent
+	Handoffs int
+
+	results chan *agentResult
+}
+
+type IntersectionAgent struct {
+	ID         string
+	Approaches []string
+	Downstream map[string]string
+	Upstream   map[string]string
+	Incoming   map[string]PlatoonHandoff
+	Capacity   map[string]int
+
+	coordinator *AgentCoordinator
+	neighbours  map[string]*IntersectionAgent
+	input       chan *agentSnapshot
+	inbox       chan AgentMessage
+	pending     []AgentMessage
+	handedOff   map[string]bool
+	sequence    int
+}
+
+type AgentMessage struct {
+	From     string
+	Step     int
+	Sequence int
+	Handoff  *PlatoonHandoff
+	Capacity *EdgeCapacity
+}
+
+type PlatoonHandoff struct {
+	PlatoonID string  `json:"platoon"`
+	Size      int     `json:"size"`
+	Edge      string  `json:"edge"`
+	ETA       float64 `json:"eta"`
+}
+
+type EdgeCapacity struct {
+	Edge string `json:"edge"`
+	Free int    `json:"free"`
+}
+
+type agentSnapshot struct {
+	step     int
+	now      time.Time
+	view     *TrafficManager
+	original map[string]float64
+}
+
+type agentResult struct {
+	agentID      string
+	vehicles     map[string]*models.Vehicle
+	speeds       map[string]float64
+	platoons     map[string]*models.Platoon
+	intersection *models.Intersection
+	grants       []*PriorityGrant
+	dilemma      *DilemmaZoneGuard
+	handoffs     int
+}
+
+func NewAgentCoordinator() *AgentCoordinator {
+	return &AgentCoordinator{
+		Agents:  make(map[string]*IntersectionAgent),
+		results: make(chan *agentResult),
+	}
+}
+
+func (tm *TrafficManager) newIntersectionAgent(intersectionID string) *IntersectionAgent {
+	ac := tm.Agents
+	agent := &IntersectionAgent{
+		ID:          intersectionID,
+		Approaches:  tm.getJunctionApproaches(intersectionID),
+		Downstream:  make(map[string]string),
+		Upstream:    make(map[string]string),
+		Incoming:    make(map[string]PlatoonHandoff),
+		Capacity:    make(map[string]int),
+		coordinator: ac,
+		neighbours:  make(map[string]*IntersectionAgent),
+		input:       make(chan *agentSnapshot),
+		inbox:       make(chan AgentMessage, AgentInboxSize),
+		handedOff:   make(map[string]bool),
+	}
+
+	junctions := tm.getEdgeJunctions()
+	for _, edge := range agent.Approaches {
+		for _, direction := range []string{models.TurnLeft, models.TurnStraight, models.TurnRight} {
+			exit := tm.getMovementExitEdge(edge, direction)
+			if neighbour, exists := junctions[exit]; exists && neighbour != intersectionID {
+				agent.Downstream[exit] = neighbour
+			}
+		}
+	}
+
+	for _, other := range ac.Agents {
+		for exit, neighbour := range other.Downstream {
+			if neighbour == agent.ID {
+				ac.connect(other, exit, agent)
+			}
+		}
+		for exit, neighbour := range agent.Downstream {
+			if neighbour == other.ID {
+				ac.connect(agent, exit, other)
+			}
+		}
+	}
+
+	go agent.run()
+
+	log.Printf("agent %s: started with %d approaches, %d downstream and %d upstream neighbours",
+		agent.ID, len(agent.Approaches), len(agent.Downstream), len(agent.Upstream))
+
+	return agent
+}
+
+func (ac *AgentCoordinator) connect(upstream *IntersectionAgent, exit string, downstream *IntersectionAgent) {
+	upstream.Downstream[exit] = downstream.ID
+	downstream.Upstream[exit] = upstream.ID
+	upstream.neighbours[downstream.ID] = downstream
+	downstream.neighbours[upstream.ID] = upstream
+}
+
+func (agent *IntersectionAgent) run() {
+	for {
+		select {
+		case snapshot, ok := <-agent.input:
+			if !ok {
+				return
+			}
+			agent.coordinator.results <- agent.step(snapshot)
+		case message := <-agent.inbox:
+			agent.pending = append(agent.pending, message)
+		}
+	}
+}
+
+func (agent *IntersectionAgent) send(to *IntersectionAgent, message AgentMessage) {
+	agent.sequence++
+	message.From = agent.ID
+	message.Sequence = agent.sequence
+
+	to.inbox <- message
+}
+
+func (ac *AgentCoordinator) Stop() {
+	for _, agent := range ac.Agents {
+		close(agent.input)
+	}
+	ac.Agents = make(map[string]*IntersectionAgent)
+}
+
+func (ac *AgentCoordinator) Step(tm *TrafficManager) {
+	ids := slices.Sorted(maps.Keys(tm.Intersections))
+	for _, id := range ids {
+		if _, exists := ac.Agents[id]; !exists {
+			ac.Agents[id] = tm.newIntersectionAgent(id)
+		}
+	}
+
+	now := tm.SimulationClock()
+	agentIDs := slices.Sorted(maps.Keys(ac.Agents))
+
+	for _, id := range agentIDs {
+		view, original := tm.buildAgentView(ac.Agents[id])
+		ac.Agents[id].input <- &agentSnapshot{
+			step:     tm.TimeStep,
+			now:      now,
+			view:     view,
+			original: original,
+		}
+	}
+
+	results := make(map[string]*agentResult, len(agentIDs))
+	for range agentIDs {
+		result := <-ac.results
+		results[result.agentID] = result
+	}
+
+	for _, id := range agentIDs {
+		tm.applyAgentResult(results[id])
+	}
+}
+
+func (tm *TrafficManager) buildAgentView(agent *IntersectionAgent) (*TrafficManager, map[string]float64) {
+	view := &TrafficManager{
+		Vehicles:                 make(map[string]*models.Vehicle),
+		Platoons:                 make(map[string]*models.Platoon),
+		Intersections:            make(map[string]*models.Intersection),
+		VehicleToPlatoon:         make(map[string]string),
+		IntersectionReservations: make(map[string]*models.IntersectionReservation),
+
+		MaxRegularSpeed:    tm.MaxRegularSpeed,
+		MaxPlatoonSpeed:    tm.MaxPlatoonSpeed,
+		StablePlatoonSpeed: tm.StablePlatoonSpeed,
+
+		Algorithm:         tm.Algorithm,
+		TimeStep:          tm.TimeStep,
+		StepLength:        tm.StepLength,
+		VehicleTypes:      tm.VehicleTypes,
+		VehicleClasses:    tm.VehicleClasses,
+		Preemption:        tm.Preemption,
+		PriorityPolicy:    tm.PriorityPolicy,
+		PriorityGrants:    make([]*PriorityGrant, 0),
+		MaxPriorityGrants: tm.MaxPriorityGrants,
+		Dilemma:           tm.Dilemma.forView(),
+	}
+
+	local := make(map[string]bool)
+	for _, edge := range agent.Approaches {
+		local[edge] = true
+		for _, direction := range []string{models.TurnLeft, models.TurnStraight, models.TurnRight} {
+			local[tm.getMovementExitEdge(edge, direction)] = true
+		}
+	}
+
+	for id, vehicle := range tm.Vehicles {
+		if local[vehicle.Edge] || strings.HasPrefix(vehicle.Edge, agent.ID+"_") {
+			if platoonID, inPlatoon := tm.VehicleToPlatoon[id]; inPlatoon {
+				if platoon, exists := tm.Platoons[platoonID]; exists {
+					copied := *platoon
+					view.Platoons[platoonID] = &copied
+				}
+			}
+		}
+	}
+
+	for id, vehicle := range tm.Vehicles {
+		platoonID, inPlatoon := tm.VehicleToPlatoon[id]
+		if !local[vehicle.Edge] && !strings.HasPrefix(vehicle.Edge, agent.ID+"_") && view.Platoons[platoonID] == nil {
+			continue
+		}
+
+		copied := *vehicle
+		view.Vehicles[id] = &copied
+		if inPlatoon {
+			view.VehicleToPlatoon[id] = platoonID
+		}
+	}
+
+	if intersection, exists := tm.Intersections[agent.ID]; exists {
+		copied := *intersection
+		copied.Vehicles = append([]string(nil), intersection.Vehicles...)
+		copied.ClearingVehicles = append([]string(nil), intersection.ClearingVehicles...)
+		view.Intersections[agent.ID] = &copied
+	}
+
+	for id, reservation := range tm.IntersectionReservations {
+		if reservation.IntersectionID == agent.ID {
+			copied := *reservation
+			view.IntersectionReservations[id] = &copied
+		}
+	}
+
+	original := make(map[string]float64, len(view.Vehicles))
+	for id, vehicle := range view.Vehicles {
+		original[id] = vehicle.DesiredSpeed
+	}
+
+	return view, original
+}
+
+func (agent *IntersectionAgent) step(snapshot *agentSnapshot) *agentResult {
+	view := snapshot.view
+	agent.receive(snapshot.step, view)
+
+	if intersection, exists := view.Intersections[agent.ID]; exists {
+		view.controlIntersection(agent.ID, intersection, snapshot.now)
+	}
+
+	agent.holdForDownstream(view)
+
+	result := &agentResult{
+		agentID:      agent.ID,
+		vehicles:     view.Vehicles,
+		speeds:       make(map[string]float64),
+		platoons:     view.Platoons,
+		intersection: view.Intersections[agent.ID],
+		grants:       view.PriorityGrants,
+		dilemma:      view.Dilemma,
+		handoffs:     agent.publish(snapshot.step, view),
+	}
+
+	for id, vehicle := range view.Vehicles {
+		if vehicle.DesiredSpeed != snapshot.original[id] {
+			result.speeds[id] = vehicle.DesiredSpeed
+		}
+	}
+
+	return result
+}
+
+func (agent *IntersectionAgent) receive(step int, view *TrafficManager) {
+	for len(agent.inbox) > 0 {
+		agent.pending = append(agent.pending, <-agent.inbox)
+	}
+
+	current := make([]AgentMessage, 0, len(agent.pending))
+	later := make([]AgentMessage, 0)
+
+	for _, message := range agent.pending {
+		if message.Step < step {
+			current = append(current, message)
+		} else {
+			later = append(later, message)
+		}
+	}
+	agent.pending = later
+
+	sort.Slice(current, func(i, j int) bool {
+		if current[i].From != current[j].From {
+			return current[i].From < current[j].From
+		}
+		return current[i].Sequence < current[j].Sequence
+	})
+
+	for _, message := range current {
+		if message.Capacity != nil {
+			agent.Capacity[message.Capacity.Edge] = message.Capacity.Free
+		}
+		if message.Handoff != nil {
+			agent.Incoming[message.Handoff.PlatoonID] = *message.Handoff
+			log.Printf("agent %s: expecting platoon %s (%d vehicles) from %s on %s at %.1f",
+				agent.ID, message.Handoff.PlatoonID, message.Handoff.Size, message.From,
+				message.Handoff.Edge, message.Handoff.ETA)
+		}
+	}
+
+	for platoonID, handoff := range agent.Incoming {
+		platoon, known := view.Platoons[platoonID]
+		if known {
+			if leader, exists := view.Vehicles[platoon.LeaderID]; exists && leader.Edge == handoff.Edge {
+				delete(agent.Incoming, platoonID)
+				continue
+			}
+		}
+		if handoff.ETA < view.SimulationTime()-30 {
+			delete(agent.Incoming, platoonID)
+		}
+	}
+}
+
+func (agent *IntersectionAgent) holdForDownstream(view *TrafficManager) {
+	if len(agent.Capacity) == 0 {
+		return
+	}
+
+	ids := slices.Sorted(maps.Keys(view.Vehicles))
+	for _, id := range ids {
+		vehicle := view.Vehicles[id]
+		if !slices.Contains(agent.Approaches, vehicle.Edge) || !vehicle.AtIntersection {
+			continue
+		}
+
+		exit := view.getMovementExitEdge(vehicle.Edge, view.getVehicleDirection(vehicle))
+		if free, reported := agent.Capacity[exit]; reported && free <= 0 && vehicle.DesiredSpeed > 0 {
+			vehicle.DesiredSpeed = 0
+			log.Printf("agent %s: holding %s, downstream %s reported full by %s",
+				agent.ID, vehicle.ID, exit, agent.Downstream[exit])
+		}
+	}
+}
+
+func (agent *IntersectionAgent) publish(step int, view *TrafficManager) int {
+	handoffs := 0
+
+	for _, edge := range slices.Sorted(maps.Keys(agent.Upstream)) {
+		neighbour := agent.neighbours[agent.Upstream[edge]]
+		capacity := &EdgeCapacity{Edge: edge, Free: agent.freeStorage(view, edge)}
+		agent.send(neighbour, AgentMessage{Step: step, Capacity: capacity})
+	}
+
+	present := make(map[string]bool)
+	for _, platoonID := range slices.Sorted(maps.Keys(view.Platoons)) {
+		platoon := view.Platoons[platoonID]
+		present[platoonID] = true
+
+		leader, exists := view.Vehicles[platoon.LeaderID]
+		if !exists || agent.handedOff[platoonID] {
+			continue
+		}
+
+		exit := leader.Edge
+		if movement, _, internal := view.getInternalEdgeMovement(leader.Edge); internal {
+			exit = view.getMovementExitEdge(movement.Edge, movement.Direction)
+		}
+
+		neighbourID, downstream := agent.Downstream[exit]
+		if !downstream {
+			continue
+		}
+
+		distance := view.getEdgeLengths()[exit]
+		if exit == leader.Edge {
+			distance -= leader.Pos
+		}
+
+		handoff := &PlatoonHandoff{
+			PlatoonID: platoonID,
+			Size:      len(platoon.VehicleIDs),
+			Edge:      exit,
+			ETA:       view.SimulationTime() + distance/math.Max(leader.Speed, 1.0),
+		}
+		agent.send(agent.neighbours[neighbourID], AgentMessage{Step: step, Handoff: handoff})
+		agent.handedOff[platoonID] = true
+		handoffs++
+	}
+
+	for platoonID := range agent.handedOff {
+		if !present[platoonID] {
+			delete(agent.handedOff, platoonID)
+		}
+	}
+
+	return handoffs
+}
+
+func (agent *IntersectionAgent) freeStorage(view *TrafficManager, edge string) int {
+	car := view.VehicleTypes["car"]
+	storage := int(view.getEdgeLengths()[edge] / (car.Length + car.MinGap))
+
+	for _, vehicle := range view.Vehicles {
+		if vehicle.Edge == edge {
+			storage--
+		}
+	}
+	for _, handoff := range agent.Incoming {
+		if handoff.Edge == edge {
+			storage -= handoff.Size
+		}
+	}
+
+	return storage
+}
+
+func (tm *TrafficManager) applyAgentResult(result *agentResult) {
+	for _, id := range slices.Sorted(maps.Keys(result.speeds)) {
+		if vehicle, exists := tm.Vehicles[id]; exists {
+			vehicle.DesiredSpeed = result.speeds[id]
+		}
+	}
+
+	for id, local := range result.vehicles {
+		if vehicle, exists := tm.Vehicles[id]; exists && vehicle.TurnDirection == "" {
+			vehicle.TurnDirection = local.TurnDirection
+		}
+	}
+
+	for id, local := range result.platoons {
+		if platoon, exists := tm.Platoons[id]; exists {
+			platoon.PriorityUntil = local.PriorityUntil
+		}
+	}
+
+	if result.intersection != nil {
+		if intersection, exists := tm.Intersections[result.agentID]; exists {
+			intersection.LastPlatoonPassTime = result.intersection.LastPlatoonPassTime
+			intersection.PriorityEdge = result.intersection.PriorityEdge
+			intersection.ClearanceUntil = result.intersection.ClearanceUntil
+			intersection.ClearingVehicles = result.intersection.ClearingVehicles
+		}
+	}
+
+	for _, grant := range result.grants {
+		tm.storePriorityGrant(grant)
+	}
+
+	for _, decision := range result.dilemma.Decisions {
+		tm.recordDilemmaDecision(decision)
+	}
+	tm.Dilemma.AllReds += result.dilemma.AllReds
+	tm.Agents.Handoffs += result.handoffs
+}
+
+func (tm *TrafficManager) summarizeAgents() map[string]map[string]interface{} {
+	summary := make(map[string]map[string]interface{})
+	for id, agent := range tm.Agents.Agents {
+		summary[id] = map[string]interface{}{
+			"downstream": agent.Downstream,
+			"capacity":   agent.Capacity,
+			"incoming":   len(agent.Incoming),
+		}
+	}
+	return summary
+}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func runChainedJunctions(arrivals []SimulatedArrival) ([]map[string]float64, int, int) {
+	tm := NewTrafficManager()
+	defer tm.Agents.Stop()
+
+	ac := tm.Agents
+	upstream := tm.newIntersectionAgent(":C2")
+	downstream := tm.newIntersectionAgent(":C3")
+	downstream.Approaches = []string{"down_leaving"}
+	ac.connect(upstream, "down_leaving", downstream)
+	ac.Agents[upstream.ID] = upstream
+	ac.Agents[downstream.ID] = downstream
+
+	sim := NewJunctionSimulator(tm, arrivals)
+	commands := make([]map[string]float64, 0)
+	capacityReports := 0
+
+	for step := 0; step < 400 && !sim.Done(); step++ {
+		sim.Step()
+		commands = append(commands, tm.GetDesiredSpeeds())
+		if _, reported := upstream.Capacity["down_leaving"]; reported {
+			capacityReports++
+		}
+	}
+
+	return commands, ac.Handoffs, capacityReports
+}
+
+func TestChainedJunctionAgentsAreDeterministic(t *testing.T) {
+	arrivals := UniformArrivals(7, 300, 0.15)
+
+	first, handoffs, reports := runChainedJunctions(arrivals)
+	second, _, _ := runChainedJunctions(arrivals)
+
+	if handoffs == 0 {
+		t.Fatal("no platoon handoffs were sent to the downstream junction")
+	}
+	if reports == 0 {
+		t.Fatal("the upstream junction never received a capacity report")
+	}
+
+	if len(first) != len(second) {
+		t.Fatalf("runs took %d and %d steps", len(first), len(second))
+	}
+	for step := range first {
+		if !reflect.DeepEqual(first[step], second[step]) {
+			t.Fatalf("commands differ at step %d", step)
+		}
+	}
+}
@@ -1,10 +1,11 @@
 package manager
 
 import (
-	"fmt"
 	"log"
+	"maps"
 	"math"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -151,6 +152,10 @@ func (tm *TrafficManager) calculateTurnDirectionFromEdges(currentEdge, nextEdge
 }
 
 func (tm *TrafficManager) getVehicleRouteEdges(vehicleID string) []string {
+	if vehicle, exists := tm.Vehicles[vehicleID]; exists && len(vehicle.Route) > 0 {
+		return vehicle.Route
+	}
+
 	routeType := ""
 
 	if strings.Contains(vehicleID, "up_to_left") {
@@ -191,113 +196,67 @@ func (tm *TrafficManager) getVehicleRouteEdges(vehicleID string) []string {
 
 func (tm *TrafficManager) ManageIntersections() {
 	tm.updatePlatoonWaitTimes()
-	tm.enforcePlatoonSizeLimits(15)
-
-	for intersectionID, intersection := range tm.Intersections {
-		if len(intersection.Vehicles) < 2 {
-			continue
-		}
-
-		leftTurn := make([]*models.Vehicle, 0)
-		rightTurn := make([]*models.Vehicle, 0)
-		straight := make([]*models.Vehicle, 0)
-
-		vehiclesByEdge := make(map[string][]*models.Vehicle)
-		platoonsByEdge := make(map[string][]string)
-
-		for _, vehicleID := range intersection.Vehicles {
-			vehicle, exists := tm.Vehicles[vehicleID]
-			if !exists {
-				continue
-			}
-
-			if vehicle.TurnDirection == "" {
-				vehicle.TurnDirection = tm.determineTurnDirection(vehicle, vehicle.NextEdge)
-			}
-
-			switch vehicle.TurnDirection {
-			case models.TurnLeft:
-				leftTurn = append(leftTurn, vehicle)
-			case models.TurnRight:
-				rightTurn = append(rightTurn, vehicle)
-			default:
-				straight = append(straight, vehicle)
-			}
-
-			edgeKey := vehicle.Edge
-			if len(edgeKey) > 0 && edgeKey[0] == ':' {
-				if sourceEdge := tm.getSourceEdgeForInternal(vehicle); sourceEdge != "" {
-					edgeKey = sourceEdge
-				}
-			}
-
-			vehiclesByEdge[edgeKey] = append(vehiclesByEdge[edgeKey], vehicle)
-
-			if platoonID, hasPlatoon := tm.VehicleToPlatoon[vehicleID]; hasPlatoon {
-				if !tm.containsPlatoon(platoonsByEdge[edgeKey], platoonID) {
-					platoonsByEdge[edgeKey] = append(platoonsByEdge[edgeKey], platoonID)
-				}
-			}
-		}
+	tm.SplitDivergingPlatoons()
+	tm.EnforcePlatoonSizePolicy()
 
-		tm.handleForcedPriorityPlatoons(intersectionID, vehiclesByEdge, platoonsByEdge)
-		tm.handleReservations(intersectionID, vehiclesByEdge, platoonsByEdge)
-		tm.handlePriorityPlatoons(intersectionID, intersection, vehiclesByEdge, platoonsByEdge)
-		tm.handleNonConflictingMovements(intersectionID, vehiclesByEdge, platoonsByEdge, leftTurn, rightTurn, straight)
-	}
+	tm.Agents.Step(tm)
 
 	tm.handlePostIntersectionVehicles()
 }
 
-func (tm *TrafficManager) enforcePlatoonSizeLimits(maxSize int) {
-	for platoonID, platoon := range tm.Platoons {
-		if len(platoon.VehicleIDs) > maxSize {
-			log.Printf("splitting large platoon %s with %d vehicles", platoonID, len(platoon.VehicleIDs))
-
-			sortedVehicles := make([]*models.Vehicle, 0, len(platoon.VehicleIDs))
-			for _, vid := range platoon.VehicleIDs {
-				if v, exists := tm.Vehicles[vid]; exists {
-					sortedVehicles = append(sortedVehicles, v)
-				}
-			}
+func (tm *TrafficManager) controlIntersection(intersectionID string, intersection *models.Intersection, now time.Time) {
+	tm.endPriorityClearance(intersection, now)
 
-			sort.Slice(sortedVehicles, func(i, j int) bool {
-				return sortedVehicles[i].Pos > sortedVehicles[j].Pos
-			})
+	if len(intersection.Vehicles) < 2 {
+		return
+	}
 
-			firstGroup := sortedVehicles[:maxSize]
-			secondGroup := sortedVehicles[maxSize:]
+	leftTurn := make([]*models.Vehicle, 0)
+	rightTurn := make([]*models.Vehicle, 0)
+	straight := make([]*models.Vehicle, 0)
 
-			if len(secondGroup) > 0 {
-				newPlatoonID := fmt.Sprintf("p_split_%s_%d", platoon.Edge, tm.TimeStep)
-				newLeader := secondGroup[0]
+	vehiclesByEdge := make(map[string][]*models.Vehicle)
+	platoonsByEdge := make(map[string][]string)
 
-				newPlatoon := &models.Platoon{
-					ID:         newPlatoonID,
-					VehicleIDs: make([]string, 0, len(secondGroup)),
-					LeaderID:   newLeader.ID,
-					Edge:       platoon.Edge,
-					Lane:       newLeader.Lane,
-				}
+	for _, vehicleID := range intersection.Vehicles {
+		vehicle, exists := tm.Vehicles[vehicleID]
+		if !exists {
+			continue
+		}
 
-				for _, v := range secondGroup {
-					newPlatoon.VehicleIDs = append(newPlatoon.VehicleIDs, v.ID)
+		if vehicle.TurnDirection == "" {
+			vehicle.TurnDirection = tm.determineTurnDirection(vehicle, vehicle.NextEdge)
+		}
 
-					v.PlatoonID = newPlatoonID
-					v.IsLeader = (v.ID == newLeader.ID)
+		switch vehicle.TurnDirection {
+		case models.TurnLeft:
+			leftTurn = append(leftTurn, vehicle)
+		case models.TurnRight:
+			rightTurn = append(rightTurn, vehicle)
+		default:
+			straight = append(straight, vehicle)
+		}
 
-					tm.VehicleToPlatoon[v.ID] = newPlatoonID
-				}
+		edgeKey := vehicle.Edge
+		if len(edgeKey) > 0 && edgeKey[0] == ':' {
+			if sourceEdge := tm.getSourceEdgeForInternal(vehicle); sourceEdge != "" {
+				edgeKey = sourceEdge
+			}
+		}
 
-				tm.Platoons[newPlatoonID] = newPlatoon
+		vehiclesByEdge[edgeKey] = append(vehiclesByEdge[edgeKey], vehicle)
 
-				platoon.VehicleIDs = make([]string, 0, len(firstGroup))
-				for _, v := range firstGroup {
-					platoon.VehicleIDs = append(platoon.VehicleIDs, v.ID)
-				}
+		if platoonID, hasPlatoon := tm.VehicleToPlatoon[vehicleID]; hasPlatoon {
+			if !tm.containsPlatoon(platoonsByEdge[edgeKey], platoonID) {
+				platoonsByEdge[edgeKey] = append(platoonsByEdge[edgeKey], platoonID)
 			}
 		}
 	}
+
+	tm.handleForcedPriorityPlatoons(intersectionID, intersection, now, vehiclesByEdge, platoonsByEdge)
+	tm.handleReservations(intersectionID, now, vehiclesByEdge, platoonsByEdge)
+	tm.handlePriorityPlatoons(intersectionID, intersection, now, vehiclesByEdge, platoonsByEdge)
+	tm.handleNonConflictingMovements(intersectionID, vehiclesByEdge, platoonsByEdge, leftTurn, rightTurn, straight)
 }
 
 func (tm *TrafficManager) updatePlatoonWaitTimes() {
@@ -328,21 +287,19 @@ func (tm *TrafficManager) updatePlatoonWaitTimes() {
 			} else if len(platoon.VehicleIDs) >= 3 {
 				platoon.IntersectionWaitTime += 2
 			}
-		} else {
-			platoon.IntersectionWaitTime = 0
 		}
 	}
 }
 
-func (tm *TrafficManager) handleForcedPriorityPlatoons(intersectionID string,
+func (tm *TrafficManager) handleForcedPriorityPlatoons(intersectionID string, intersection *models.Intersection, now time.Time,
 	vehiclesByEdge map[string][]*models.Vehicle, platoonsByEdge map[string][]string) {
 
-	now := time.Now()
+	edges := slices.Sorted(maps.Keys(platoonsByEdge))
 
-	for edge, platoons := range platoonsByEdge {
-		for _, platoonID := range platoons {
+	for _, edge := range edges {
+		for _, platoonID := range platoonsByEdge[edge] {
 			platoon, exists := tm.Platoons[platoonID]
-			if !exists {
+			if !exists || tm.isPriorityPreempted(intersectionID, platoon) {
 				continue
 			}
 
@@ -376,7 +333,7 @@ func (tm *TrafficManager) handleForcedPriorityPlatoons(intersectionID string,
 					}
 
 					for _, v := range vehicles {
-						v.DesiredSpeed = 0.0
+						tm.holdForPriority(intersection, v)
 					}
 				}
 
@@ -385,10 +342,15 @@ func (tm *TrafficManager) handleForcedPriorityPlatoons(intersectionID string,
 		}
 	}
 
-	for edge, platoons := range platoonsByEdge {
-		for _, platoonID := range platoons {
+	if now.Before(intersection.ClearanceUntil) {
+		tm.holdDuringClearance(intersection, vehiclesByEdge)
+		return
+	}
+
+	for _, edge := range edges {
+		for _, platoonID := range platoonsByEdge[edge] {
 			platoon, exists := tm.Platoons[platoonID]
-			if !exists {
+			if !exists || tm.isPriorityPreempted(intersectionID, platoon) {
 				continue
 			}
 
@@ -397,15 +359,23 @@ func (tm *TrafficManager) handleForcedPriorityPlatoons(intersectionID string,
 				continue
 			}
 
-			if len(platoon.VehicleIDs) < 5 && platoon.IntersectionWaitTime < 60 {
+			policy := tm.PriorityPolicy
+			if len(platoon.VehicleIDs) < policy.ForcedMinSize && platoon.IntersectionWaitTime < policy.ForcedMinWait {
 				continue
 			}
 
+			if tm.startPriorityClearance(intersectionID, intersection, now, edge, vehiclesByEdge) {
+				tm.holdDuringClearance(intersection, vehiclesByEdge)
+				return
+			}
+			intersection.PriorityEdge = edge
+
 			log.Printf("FORCED PRIORITY for platoon %s (size: %d, wait: %d) at intersection %s",
 				platoonID, len(platoon.VehicleIDs), platoon.IntersectionWaitTime, intersectionID)
 
-			priorityDuration := now.Add(15 * time.Second)
+			priorityDuration := now.Add(policy.priorityWindow())
 			platoon.PriorityUntil = &priorityDuration
+			tm.recordPriorityGrant(intersectionID, edge, platoon, tm.scorePlatoonPriority(platoon, edge, vehiclesByEdge), true)
 
 			leader.DesiredSpeed = math.Min(leader.Speed+5.0, 19.4)
 
@@ -428,7 +398,7 @@ func (tm *TrafficManager) handleForcedPriorityPlatoons(intersectionID string,
 				}
 
 				for _, v := range vehicles {
-					v.DesiredSpeed = 0.0
+					tm.holdForPriority(intersection, v)
 				}
 			}
 
@@ -437,19 +407,25 @@ func (tm *TrafficManager) handleForcedPriorityPlatoons(intersectionID string,
 	}
 }
 
-func (tm *TrafficManager) handlePriorityPlatoons(intersectionID string, intersection *models.Intersection,
+func (tm *TrafficManager) handlePriorityPlatoons(intersectionID string, intersection *models.Intersection, now time.Time,
 	vehiclesByEdge map[string][]*models.Vehicle, platoonsByEdge map[string][]string) {
 
-	now := time.Now()
+	policy := tm.PriorityPolicy
+	edges := slices.Sorted(maps.Keys(platoonsByEdge))
+
+	if now.Sub(intersection.LastPlatoonPassTime).Seconds() < policy.PassGap {
+		return
+	}
 
-	if now.Sub(intersection.LastPlatoonPassTime).Seconds() < 3.0 {
+	if now.Before(intersection.ClearanceUntil) {
+		tm.holdDuringClearance(intersection, vehiclesByEdge)
 		return
 	}
 
-	for edge, platoons := range platoonsByEdge {
-		for _, platoonID := range platoons {
+	for _, edge := range edges {
+		for _, platoonID := range platoonsByEdge[edge] {
 			platoon, exists := tm.Platoons[platoonID]
-			if !exists {
+			if !exists || tm.isPriorityPreempted(intersectionID, platoon) {
 				continue
 			}
 
@@ -485,12 +461,12 @@ func (tm *TrafficManager) handlePriorityPlatoons(intersectionID string, intersec
 					for _, vehicle := range vehicles {
 						otherPlatoonID, inPlatoon := tm.VehicleToPlatoon[vehicle.ID]
 						if !inPlatoon {
-							vehicle.DesiredSpeed = 0.0
+							tm.holdForPriority(intersection, vehicle)
 						} else {
 							otherPlatoon, exists := tm.Platoons[otherPlatoonID]
 							if !exists || otherPlatoon.PriorityUntil == nil ||
 								now.After(*otherPlatoon.PriorityUntil) {
-								vehicle.DesiredSpeed = 0.0
+								tm.holdForPriority(intersection, vehicle)
 							}
 						}
 					}
@@ -506,45 +482,30 @@ func (tm *TrafficManager) handlePriorityPlatoons(intersectionID string, intersec
 		platoonID     string
 		edge          string
 		priorityScore float64
+		score         PriorityScore
 	}
 
 	var priorityQueue []PlatoonPriority
 
-	for edge, platoons := range platoonsByEdge {
-		for _, platoonID := range platoons {
+	for _, edge := range edges {
+		for _, platoonID := range platoonsByEdge[edge] {
 			platoon, exists := tm.Platoons[platoonID]
-			if !exists {
+			if !exists || tm.isPriorityPreempted(intersectionID, platoon) {
 				continue
 			}
 
 			leaderVehicle, exists := tm.Vehicles[platoon.LeaderID]
-			if !exists || leaderVehicle.Speed > 3.0 {
+			if !exists || leaderVehicle.Speed > policy.MaxLeaderSpeed {
 				continue
 			}
 
-			size := len(platoon.VehicleIDs)
-			waitTime := platoon.IntersectionWaitTime
-
-			score := float64(size)*20.0 + float64(waitTime)*10.0
-
-			if size >= 5 {
-				score += 150.0
-			} else if size >= 3 {
-				score += 75.0
-			}
-
-			if waitTime > 60 {
-				score += 300.0
-			} else if waitTime > 30 {
-				score += 150.0
-			} else if waitTime > 15 {
-				score += 75.0
-			}
+			score := tm.scorePlatoonPriority(platoon, edge, vehiclesByEdge)
 
 			priorityQueue = append(priorityQueue, PlatoonPriority{
 				platoonID:     platoonID,
 				edge:          edge,
-				priorityScore: score,
+				priorityScore: score.Total,
+				score:         score,
 			})
 		}
 	}
@@ -553,7 +514,7 @@ func (tm *TrafficManager) handlePriorityPlatoons(intersectionID string, intersec
 		return
 	}
 
-	sort.Slice(priorityQueue, func(i, j int) bool {
+	sort.SliceStable(priorityQueue, func(i, j int) bool {
 		return priorityQueue[i].priorityScore > priorityQueue[j].priorityScore
 	})
 
@@ -563,17 +524,25 @@ func (tm *TrafficManager) handlePriorityPlatoons(intersectionID string, intersec
 		return
 	}
 
-	priorityDuration := now.Add(15 * time.Second)
+	if tm.startPriorityClearance(intersectionID, intersection, now, highestPriority.edge, vehiclesByEdge) {
+		tm.holdDuringClearance(intersection, vehiclesByEdge)
+		return
+	}
+	intersection.PriorityEdge = highestPriority.edge
+
+	priorityDuration := now.Add(policy.priorityWindow())
 	platoon.PriorityUntil = &priorityDuration
+	tm.recordPriorityGrant(intersectionID, highestPriority.edge, platoon, highestPriority.score, false)
 
 	leader, exists := tm.Vehicles[platoon.LeaderID]
 	if !exists {
 		return
 	}
 
-	log.Printf("giving priority to platoon %s on edge %s with score %.1f (size: %d, wait: %d)",
+	occupancy, _ := tm.calculatePlatoonOccupancy(platoon)
+	log.Printf("giving priority to platoon %s on edge %s with score %.1f (size: %d, persons: %.1f, wait: %d)",
 		platoon.ID, highestPriority.edge, highestPriority.priorityScore,
-		len(platoon.VehicleIDs), platoon.IntersectionWaitTime)
+		len(platoon.VehicleIDs), occupancy, platoon.IntersectionWaitTime)
 
 	leader.DesiredSpeed = math.Min(leader.Speed+4.0, 16.7)
 
@@ -598,11 +567,11 @@ func (tm *TrafficManager) handlePriorityPlatoons(intersectionID string, intersec
 		for _, vehicle := range vehicles {
 			otherPlatoonID, inPlatoon := tm.VehicleToPlatoon[vehicle.ID]
 			if !inPlatoon {
-				vehicle.DesiredSpeed = 0.0
+				tm.holdForPriority(intersection, vehicle)
 			} else {
 				otherPlatoon, exists := tm.Platoons[otherPlatoonID]
 				if !exists || otherPlatoon.IntersectionWaitTime < platoon.IntersectionWaitTime/2 {
-					vehicle.DesiredSpeed = 0.0
+					tm.holdForPriority(intersection, vehicle)
 				}
 			}
 		}
@@ -672,8 +641,8 @@ func (tm *TrafficManager) handlePostIntersectionVehicles() {
 }
 
 func (tm *TrafficManager) splitPlatoon(platoon *models.Platoon, edgeID string) {
-	vehiclesOnEdge := make([]*models.Vehicle, 0)
-	vehiclesNotOnEdge := make([]*models.Vehicle, 0)
+	vehiclesOnEdge := make([]string, 0)
+	vehiclesNotOnEdge := 0
 
 	for _, vid := range platoon.VehicleIDs {
 		v, exists := tm.Vehicles[vid]
@@ -682,67 +651,17 @@ func (tm *TrafficManager) splitPlatoon(platoon *models.Platoon, edgeID string) {
 		}
 
 		if v.Edge == edgeID {
-			vehiclesOnEdge = append(vehiclesOnEdge, v)
+			vehiclesOnEdge = append(vehiclesOnEdge, v.ID)
 		} else {
-			vehiclesNotOnEdge = append(vehiclesNotOnEdge, v)
+			vehiclesNotOnEdge++
 		}
 	}
 
-	if len(vehiclesOnEdge) < 2 || len(vehiclesNotOnEdge) < 1 {
+	if len(vehiclesOnEdge) < 2 || vehiclesNotOnEdge < 1 {
 		return
 	}
 
-	newPlatoonID := fmt.Sprintf("p_%s_%d", edgeID, time.Now().UnixNano())
-
-	newLeader := vehiclesOnEdge[0]
-	for _, v := range vehiclesOnEdge {
-		if v.Pos > newLeader.Pos {
-			newLeader = v
-		}
-	}
-
-	newPlatoon := &models.Platoon{
-		ID:         newPlatoonID,
-		VehicleIDs: make([]string, 0, len(vehiclesOnEdge)),
-		LeaderID:   newLeader.ID,
-		Edge:       edgeID,
-		Lane:       newLeader.Lane,
-	}
-
-	for _, v := range vehiclesOnEdge {
-		newPlatoon.VehicleIDs = append(newPlatoon.VehicleIDs, v.ID)
-
-		if v.ID == newLeader.ID {
-			v.IsLeader = true
-		} else {
-			v.IsLeader = false
-		}
-
-		v.PlatoonID = newPlatoonID
-		tm.VehicleToPlatoon[v.ID] = newPlatoonID
-
-		delete(tm.VehicleToPlatoon, v.ID)
-	}
-
-	if len(newPlatoon.VehicleIDs) > 0 {
-		tm.Platoons[newPlatoonID] = newPlatoon
-	}
-
-	remainingIDs := make([]string, 0, len(vehiclesNotOnEdge))
-	for _, v := range vehiclesNotOnEdge {
-		remainingIDs = append(remainingIDs, v.ID)
-	}
-
-	if len(remainingIDs) < 2 {
-		for _, v := range vehiclesNotOnEdge {
-			v.PlatoonID = ""
-			v.IsLeader = false
-			delete(tm.VehicleToPlatoon, v.ID)
-		}
-		delete(tm.Platoons, platoon.ID)
-	} else {
-		platoon.VehicleIDs = remainingIDs
-	}
+	tm.requestManeuver(models.ManeuverSplit, platoon.ID, "", vehiclesOnEdge)
 }
 
 func (tm *TrafficManager) ensureProperSpacingOnLeavingEdge(edgeID string, vehicles []*models.Vehicle) {
@@ -798,11 +717,9 @@ func (tm *TrafficManager) containsPlatoon(platoons []string, platoonID string) b
 	return false
 }
 
-func (tm *TrafficManager) handleReservations(intersectionID string,
+func (tm *TrafficManager) handleReservations(intersectionID string, now time.Time,
 	vehiclesByEdge map[string][]*models.Vehicle, platoonsByEdge map[string][]string) {
 
-	now := time.Now()
-
 	for _, reservationID := range tm.findReservationsForIntersection(intersectionID) {
 		reservation, exists := tm.IntersectionReservations[reservationID]
 		if !exists {
@@ -821,6 +738,10 @@ func (tm *TrafficManager) handleReservations(intersectionID string,
 					continue
 				}
 
+				if vehicle.TurnDirection != "" && reservation.Direction != "" && vehicle.TurnDirection != reservation.Direction {
+					continue
+				}
+
 				if vehicle.ID == platoon.LeaderID {
 					vehicle.DesiredSpeed = math.Min(vehicle.Speed+3.0, tm.MaxPlatoonSpeed)
 				} else {
@@ -890,6 +811,7 @@ func (tm *TrafficManager) findReservationsForIntersection(intersectionID string)
 			result = append(result, id)
 		}
 	}
+	sort.Strings(result)
 	return result
 }
 
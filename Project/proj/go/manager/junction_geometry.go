@@ -0,0 +1,303 @@
+package manager
+
+import (
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+func (tm *TrafficManager) getEdgeJunctions() map[string]string {
+	junctions := map[string]string{
+		"down_incoming":  ":C2",
+		"left_incoming":  ":C2",
+		"right_incoming": ":C2",
+		"up_incoming":    ":C2",
+	}
+
+	return junctions
+}
+
+func (tm *TrafficManager) getJunctionIDs() []string {
+	seen := make(map[string]bool)
+	ids := make([]string, 0)
+
+	for _, junctionID := range tm.getEdgeJunctions() {
+		if !seen[junctionID] {
+			seen[junctionID] = true
+			ids = append(ids, junctionID)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func (tm *TrafficManager) getJunctionApproaches(junctionID string) []string {
+	approaches := make([]string, 0)
+	for edge, id := range tm.getEdgeJunctions() {
+		if id == junctionID {
+			approaches = append(approaches, edge)
+		}
+	}
+
+	sort.Strings(approaches)
+	return approaches
+}
+
+func (tm *TrafficManager) getMovementExitEdge(edgeFrom, direction string) string {
+	exits := map[string]map[string]string{
+		"down_incoming": {
+			models.TurnRight:    "left_leaving",
+			models.TurnStraight: "down_leaving",
+			models.TurnLeft:     "right_leaving",
+		},
+		"left_incoming": {
+			models.TurnRight:    "up_leaving",
+			models.TurnStraight: "left_leaving",
+			models.TurnLeft:     "down_leaving",
+		},
+		"up_incoming": {
+			models.TurnRight:    "right_leaving",
+			models.TurnStraight: "up_leaving",
+			models.TurnLeft:     "left_leaving",
+		},
+		"right_incoming": {
+			models.TurnRight:    "down_leaving",
+			models.TurnStraight: "right_leaving",
+			models.TurnLeft:     "up_leaving",
+		},
+	}
+
+	return exits[edgeFrom][direction]
+}
+
+func (tm *TrafficManager) getJunctionMovements(junctionID string) []models.Movement {
+	movements := make([]models.Movement, 0)
+	for _, edge := range tm.getJunctionApproaches(junctionID) {
+		for _, direction := range []string{models.TurnLeft, models.TurnStraight, models.TurnRight} {
+			if tm.getMovementExitEdge(edge, direction) != "" {
+				movements = append(movements, models.Movement{Edge: edge, Direction: direction})
+			}
+		}
+	}
+	return movements
+}
+
+func (tm *TrafficManager) getJunctionBounds(junctionID string) (models.Point, models.Point, bool) {
+	bounds := map[string][2]models.Point{
+		":C2": {{X: 192.78, Y: 192.79}, {X: 207.20, Y: 207.20}},
+	}
+
+	b, exists := bounds[junctionID]
+	if !exists {
+		return models.Point{}, models.Point{}, false
+	}
+
+	return b[0], b[1], true
+}
+
+func (tm *TrafficManager) getMovementPath(edgeFrom, direction string) []models.Point {
+	paths := map[string]map[string][]models.Point{
+		"down_incoming": {
+			models.TurnRight:    {{X: 198.40, Y: 207.20}, {X: 198.05, Y: 204.75}, {X: 197.00, Y: 202.99}, {X: 195.24, Y: 201.94}, {X: 192.79, Y: 201.58}},
+			models.TurnStraight: {{X: 198.40, Y: 207.20}, {X: 198.40, Y: 192.79}},
+			models.TurnLeft:     {{X: 198.40, Y: 207.20}, {X: 198.95, Y: 203.35}, {X: 199.04, Y: 203.20}, {X: 200.60, Y: 200.60}, {X: 203.35, Y: 198.95}, {X: 207.20, Y: 198.40}},
+		},
+		"left_incoming": {
+			models.TurnRight:    {{X: 207.20, Y: 201.60}, {X: 204.75, Y: 201.95}, {X: 203.00, Y: 203.00}, {X: 201.95, Y: 204.75}, {X: 201.60, Y: 207.20}},
+			models.TurnStraight: {{X: 207.20, Y: 201.60}, {X: 192.79, Y: 201.58}},
+			models.TurnLeft:     {{X: 207.20, Y: 201.60}, {X: 203.35, Y: 201.05}, {X: 200.60, Y: 199.40}, {X: 198.95, Y: 196.65}, {X: 198.40, Y: 192.79}},
+		},
+		"up_incoming": {
+			models.TurnRight:    {{X: 201.60, Y: 192.79}, {X: 201.95, Y: 195.24}, {X: 203.00, Y: 197.00}, {X: 204.75, Y: 198.05}, {X: 207.20, Y: 198.40}},
+			models.TurnStraight: {{X: 201.60, Y: 192.79}, {X: 201.60, Y: 207.20}},
+			models.TurnLeft:     {{X: 201.60, Y: 192.79}, {X: 201.05, Y: 196.64}, {X: 200.96, Y: 196.80}, {X: 199.40, Y: 199.39}, {X: 196.64, Y: 201.04}, {X: 192.79, Y: 201.58}},
+		},
+		"right_incoming": {
+			models.TurnRight:    {{X: 192.79, Y: 198.38}, {X: 195.25, Y: 198.04}, {X: 197.00, Y: 196.99}, {X: 198.05, Y: 195.24}, {X: 198.40, Y: 192.79}},
+			models.TurnStraight: {{X: 192.79, Y: 198.38}, {X: 207.20, Y: 198.40}},
+			models.TurnLeft:     {{X: 192.79, Y: 198.38}, {X: 196.65, Y: 198.94}, {X: 199.40, Y: 200.60}, {X: 201.05, Y: 203.35}, {X: 201.60, Y: 207.20}},
+		},
+	}
+
+	if edgePaths, exists := paths[edgeFrom]; exists {
+		if path, hasPath := edgePaths[direction]; hasPath {
+			return path
+		}
+	}
+
+	return nil
+}
+
+func (tm *TrafficManager) getMovementSpeedLimit(direction string) float64 {
+	switch direction {
+	case models.TurnRight:
+		return 6.5
+	case models.TurnLeft:
+		return 8.0
+	default:
+		return 13.89
+	}
+}
+
+func pathLength(path []models.Point) float64 {
+	length := 0.0
+	for i := 1; i < len(path); i++ {
+		length += math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+	}
+	return length
+}
+
+func pointAlongPath(path []models.Point, distance float64) models.Point {
+	if len(path) == 0 {
+		return models.Point{}
+	}
+
+	if distance <= 0 {
+		return path[0]
+	}
+
+	for i := 1; i < len(path); i++ {
+		segment := math.Hypot(path[i].X-path[i-1].X, path[i].Y-path[i-1].Y)
+		if distance <= segment && segment > 0 {
+			ratio := distance / segment
+			return models.Point{
+				X: path[i-1].X + (path[i].X-path[i-1].X)*ratio,
+				Y: path[i-1].Y + (path[i].Y-path[i-1].Y)*ratio,
+			}
+		}
+		distance -= segment
+	}
+
+	return path[len(path)-1]
+}
+
+func (tm *TrafficManager) getInternalEdgeMovement(edge string) (models.Movement, float64, bool) {
+	type internalEdge struct {
+		movement models.Movement
+		offset   float64
+	}
+
+	internalEdges := map[string]internalEdge{
+		":C2_0":  {models.Movement{Edge: "down_incoming", Direction: models.TurnRight}, 0},
+		":C2_1":  {models.Movement{Edge: "down_incoming", Direction: models.TurnStraight}, 0},
+		":C2_2":  {models.Movement{Edge: "down_incoming", Direction: models.TurnLeft}, 0},
+		":C2_12": {models.Movement{Edge: "down_incoming", Direction: models.TurnLeft}, 4.06},
+		":C2_3":  {models.Movement{Edge: "left_incoming", Direction: models.TurnRight}, 0},
+		":C2_4":  {models.Movement{Edge: "left_incoming", Direction: models.TurnStraight}, 0},
+		":C2_5":  {models.Movement{Edge: "left_incoming", Direction: models.TurnLeft}, 0},
+		":C2_6":  {models.Movement{Edge: "up_incoming", Direction: models.TurnRight}, 0},
+		":C2_7":  {models.Movement{Edge: "up_incoming", Direction: models.TurnStraight}, 0},
+		":C2_8":  {models.Movement{Edge: "up_incoming", Direction: models.TurnLeft}, 0},
+		":C2_13": {models.Movement{Edge: "up_incoming", Direction: models.TurnLeft}, 4.07},
+		":C2_9":  {models.Movement{Edge: "right_incoming", Direction: models.TurnRight}, 0},
+		":C2_10": {models.Movement{Edge: "right_incoming", Direction: models.TurnStraight}, 0},
+		":C2_11": {models.Movement{Edge: "right_incoming", Direction: models.TurnLeft}, 0},
+	}
+
+	internal, exists := internalEdges[edge]
+	return internal.movement, internal.offset, exists
+}
+
+func (tm *TrafficManager) getMovementInternalEdges(edgeFrom, direction string) []string {
+	internal := map[string]map[string][]string{
+		"down_incoming": {
+			models.TurnRight:    {":C2_0"},
+			models.TurnStraight: {":C2_1"},
+			models.TurnLeft:     {":C2_2", ":C2_12"},
+		},
+		"left_incoming": {
+			models.TurnRight:    {":C2_3"},
+			models.TurnStraight: {":C2_4"},
+			models.TurnLeft:     {":C2_5"},
+		},
+		"up_incoming": {
+			models.TurnRight:    {":C2_6"},
+			models.TurnStraight: {":C2_7"},
+			models.TurnLeft:     {":C2_8", ":C2_13"},
+		},
+		"right_incoming": {
+			models.TurnRight:    {":C2_9"},
+			models.TurnStraight: {":C2_10"},
+			models.TurnLeft:     {":C2_11"},
+		},
+	}
+
+	return internal[edgeFrom][direction]
+}
+
+func (tm *TrafficManager) getInternalEdgeLengths() map[string]float64 {
+	return map[string]float64{
+		":C2_0":  9.05,
+		":C2_1":  14.41,
+		":C2_2":  4.06,
+		":C2_12": 10.13,
+		":C2_3":  9.03,
+		":C2_4":  14.41,
+		":C2_5":  14.20,
+		":C2_6":  9.04,
+		":C2_7":  14.41,
+		":C2_8":  4.07,
+		":C2_13": 10.13,
+		":C2_9":  9.03,
+		":C2_10": 14.41,
+		":C2_11": 14.20,
+	}
+}
+
+func segmentIntersection(a1, a2, b1, b2 models.Point) (float64, float64, bool) {
+	rX, rY := a2.X-a1.X, a2.Y-a1.Y
+	sX, sY := b2.X-b1.X, b2.Y-b1.Y
+
+	denominator := rX*sY - rY*sX
+	if math.Abs(denominator) < 1e-9 {
+		return 0, 0, false
+	}
+
+	qX, qY := b1.X-a1.X, b1.Y-a1.Y
+	t := (qX*sY - qY*sX) / denominator
+	u := (qX*rY - qY*rX) / denominator
+
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, 0, false
+	}
+
+	return t, u, true
+}
+
+func pathIntersections(pathA, pathB []models.Point) [][2]float64 {
+	points := make([][2]float64, 0)
+	offsetA := 0.0
+
+	for i := 1; i < len(pathA); i++ {
+		segmentA := math.Hypot(pathA[i].X-pathA[i-1].X, pathA[i].Y-pathA[i-1].Y)
+		offsetB := 0.0
+
+		for j := 1; j < len(pathB); j++ {
+			segmentB := math.Hypot(pathB[j].X-pathB[j-1].X, pathB[j].Y-pathB[j-1].Y)
+
+			if t, u, hit := segmentIntersection(pathA[i-1], pathA[i], pathB[j-1], pathB[j]); hit {
+				point := [2]float64{offsetA + t*segmentA, offsetB + u*segmentB}
+
+				duplicate := false
+				for _, existing := range points {
+					if math.Abs(existing[0]-point[0]) < 1.0 && math.Abs(existing[1]-point[1]) < 1.0 {
+						duplicate = true
+						break
+					}
+				}
+
+				if !duplicate {
+					points = append(points, point)
+				}
+			}
+
+			offsetB += segmentB
+		}
+
+		offsetA += segmentA
+	}
+
+	return points
+}
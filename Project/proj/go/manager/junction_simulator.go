@@ -0,0 +1,265 @@
+package manager
+
+import (
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type SimulatedArrival struct {
+	ID        string
+	Time      float64
+	EdgeFrom  string
+	Direction string
+	Type      string
+	Speed     float64
+}
+
+type JunctionSimulator struct {
+	Manager    *TrafficManager
+	Arrivals   []SimulatedArrival
+	Time       float64
+	Vehicles   map[string]*SimulatedVehicle
+	Entered    int
+	Exited     int
+	EdgeSpeed  float64
+	EntryGap   float64
+	OnJunction func(vehicle *SimulatedVehicle)
+
+	next     int
+	commands map[string]float64
+}
+
+type SimulatedVehicle struct {
+	Arrival SimulatedArrival
+	Route   []string
+	Path    []string
+	Stage   int
+	Pos     float64
+	Speed   float64
+	Entered float64
+}
+
+func NewJunctionSimulator(tm *TrafficManager, arrivals []SimulatedArrival) *JunctionSimulator {
+	sorted := append([]SimulatedArrival(nil), arrivals...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time < sorted[j].Time
+	})
+
+	return &JunctionSimulator{
+		Manager:   tm,
+		Arrivals:  sorted,
+		Vehicles:  make(map[string]*SimulatedVehicle),
+		EdgeSpeed: 13.89,
+		EntryGap:  2.0,
+		commands:  make(map[string]float64),
+	}
+}
+
+func (v *SimulatedVehicle) Edge() string {
+	return v.Path[v.Stage]
+}
+
+func (v *SimulatedVehicle) Movement() models.Movement {
+	return models.Movement{Edge: v.Arrival.EdgeFrom, Direction: v.Arrival.Direction}
+}
+
+func (v *SimulatedVehicle) OnApproach() bool {
+	return v.Stage == 0
+}
+
+func (s *JunctionSimulator) Done() bool {
+	return s.next >= len(s.Arrivals) && len(s.Vehicles) == 0
+}
+
+func (s *JunctionSimulator) Step() {
+	tm := s.Manager
+
+	s.insertArrivals()
+	tm.UpdateVehicleData(s.vehicleData())
+	tm.Update()
+
+	for id, speed := range tm.TransmitCommands(tm.GetDesiredSpeeds()) {
+		s.commands[id] = speed
+	}
+	s.move()
+
+	s.Time += tm.StepLength
+}
+
+func (s *JunctionSimulator) insertArrivals() {
+	tm := s.Manager
+
+	for s.next < len(s.Arrivals) && s.Arrivals[s.next].Time <= s.Time {
+		arrival := s.Arrivals[s.next]
+		if !s.hasEntrySpace(arrival.EdgeFrom) {
+			return
+		}
+
+		exit := tm.getMovementExitEdge(arrival.EdgeFrom, arrival.Direction)
+		path := append([]string{arrival.EdgeFrom}, tm.getMovementInternalEdges(arrival.EdgeFrom, arrival.Direction)...)
+		path = append(path, exit)
+
+		s.Vehicles[arrival.ID] = &SimulatedVehicle{
+			Arrival: arrival,
+			Route:   []string{arrival.EdgeFrom, exit},
+			Path:    path,
+			Speed:   arrival.Speed,
+			Entered: s.Time,
+		}
+		s.Entered++
+		s.next++
+	}
+}
+
+func (s *JunctionSimulator) hasEntrySpace(edge string) bool {
+	for _, vehicle := range s.Vehicles {
+		if vehicle.Edge() == edge && vehicle.Pos < s.vehicleLength(vehicle)+s.EntryGap {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *JunctionSimulator) vehicleData() map[string]map[string]interface{} {
+	data := make(map[string]map[string]interface{}, len(s.Vehicles))
+
+	for id, vehicle := range s.Vehicles {
+		route := make([]interface{}, len(vehicle.Route))
+		for i, edge := range vehicle.Route {
+			route[i] = edge
+		}
+
+		data[id] = map[string]interface{}{
+			"lane":  vehicle.Edge() + "_0",
+			"pos":   vehicle.Pos,
+			"speed": vehicle.Speed,
+			"edge":  vehicle.Edge(),
+			"route": route,
+			"type":  vehicle.Arrival.Type,
+		}
+	}
+
+	return data
+}
+
+func (s *JunctionSimulator) vehicleLength(vehicle *SimulatedVehicle) float64 {
+	return s.Manager.getVehicleType(&models.Vehicle{Type: vehicle.Arrival.Type}).Length
+}
+
+func (s *JunctionSimulator) edgeLength(edge string) float64 {
+	if length, exists := s.Manager.getEdgeLengths()[edge]; exists {
+		return length
+	}
+	return s.Manager.getInternalEdgeLengths()[edge]
+}
+
+func (s *JunctionSimulator) distanceToExit(vehicle *SimulatedVehicle) float64 {
+	distance := s.edgeLength(vehicle.Edge()) - vehicle.Pos
+	for _, edge := range vehicle.Path[vehicle.Stage+1:] {
+		distance += s.edgeLength(edge)
+	}
+	return distance
+}
+
+func (s *JunctionSimulator) move() {
+	tm := s.Manager
+
+	ids := make([]string, 0, len(s.Vehicles))
+	for id := range s.Vehicles {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		di, dj := s.distanceToExit(s.Vehicles[ids[i]]), s.distanceToExit(s.Vehicles[ids[j]])
+		if di != dj {
+			return di < dj
+		}
+		return ids[i] < ids[j]
+	})
+
+	for _, id := range ids {
+		vehicle := s.Vehicles[id]
+		vt := tm.getVehicleType(&models.Vehicle{Type: vehicle.Arrival.Type})
+
+		target := s.EdgeSpeed
+		if command, exists := s.commands[id]; exists {
+			target = command
+		}
+
+		speedLimit := s.EdgeSpeed
+		if vehicle.Stage > 0 && vehicle.Stage < len(vehicle.Path)-1 {
+			speedLimit = tm.getMovementSpeedLimit(vehicle.Arrival.Direction)
+		}
+		target = math.Min(target, speedLimit)
+
+		speed := math.Max(vehicle.Speed-vt.MaxDecel*tm.StepLength,
+			math.Min(target, vehicle.Speed+vt.MaxAccel*tm.StepLength))
+		speed = math.Max(speed, 0)
+
+		if space, exists := s.spaceAhead(vehicle, vt.MinGap); exists {
+			speed = math.Min(speed, math.Max(space, 0)/tm.StepLength)
+		}
+
+		vehicle.Speed = speed
+		vehicle.Pos += speed * tm.StepLength
+
+		for vehicle.Pos > s.edgeLength(vehicle.Edge()) {
+			vehicle.Pos -= s.edgeLength(vehicle.Edge())
+			vehicle.Stage++
+
+			if vehicle.Stage >= len(vehicle.Path) {
+				delete(s.Vehicles, id)
+				delete(s.commands, id)
+				s.Exited++
+				break
+			}
+
+			if vehicle.Stage == 1 && s.OnJunction != nil {
+				s.OnJunction(vehicle)
+			}
+		}
+	}
+}
+
+func (s *JunctionSimulator) spaceAhead(vehicle *SimulatedVehicle, minGap float64) (float64, bool) {
+	best, found := 0.0, false
+
+	for _, other := range s.Vehicles {
+		if other == vehicle || !s.sharesPath(vehicle, other) {
+			continue
+		}
+
+		ahead := s.aheadDistance(vehicle, other)
+		if ahead < 0 || (ahead == 0 && other.Arrival.ID > vehicle.Arrival.ID) {
+			continue
+		}
+
+		space := ahead - s.vehicleLength(other) - minGap
+		if !found || space < best {
+			best, found = space, true
+		}
+	}
+
+	return best, found
+}
+
+func (s *JunctionSimulator) sharesPath(vehicle, other *SimulatedVehicle) bool {
+	for _, edge := range vehicle.Path[vehicle.Stage:] {
+		if edge == other.Edge() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *JunctionSimulator) aheadDistance(vehicle, other *SimulatedVehicle) float64 {
+	distance := -vehicle.Pos
+	for _, edge := range vehicle.Path[vehicle.Stage:] {
+		if edge == other.Edge() {
+			return distance + other.Pos
+		}
+		distance += s.edgeLength(edge)
+	}
+	return math.Inf(1)
+}
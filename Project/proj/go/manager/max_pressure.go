@@ -0,0 +1,177 @@
+package manager
+
+import (
+	"log"
+	"strings"
+
+	"sumo/models"
+)
+
+type MaxPressureController struct {
+	DecisionInterval float64
+	MinPhaseTime     float64
+	ForecastHorizon  float64
+
+	phases map[string][]models.SignalPhase
+}
+
+func NewMaxPressureController() *MaxPressureController {
+	return &MaxPressureController{
+		DecisionInterval: 2.0,
+		MinPhaseTime:     6.0,
+		ForecastHorizon:  15.0,
+		phases:           make(map[string][]models.SignalPhase),
+	}
+}
+
+func (tm *TrafficManager) ManageMaxPressure() {
+	mp := tm.MaxPressure
+	sc := tm.Signals
+	now := tm.SimulationTime()
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		phases := tm.getMaxPressurePhases(junctionID)
+		if len(phases) == 0 {
+			continue
+		}
+
+		intersection := tm.getOrCreateIntersection(junctionID)
+		if intersection.CurrentControlState == nil {
+			intersection.CurrentControlState = &models.IntersectionControlState{
+				SignalInterval:    models.SignalGreen,
+				IntervalStartTime: now,
+				LastDetectionTime: now,
+			}
+		}
+
+		state := intersection.CurrentControlState
+		elapsed := now - state.IntervalStartTime
+
+		switch state.SignalInterval {
+		case models.SignalGreen:
+			if elapsed < mp.MinPhaseTime || now-state.LastDetectionTime < mp.DecisionInterval {
+				break
+			}
+
+			state.LastDetectionTime = now
+			best, bestPressure := tm.selectMaxPressurePhase(phases, state.CurrentPhase)
+			if best != state.CurrentPhase {
+				log.Printf("max-pressure %s: switching from phase %s (%.1f) to %s (%.1f)",
+					junctionID, phases[state.CurrentPhase].Name, tm.calculatePhasePressure(phases[state.CurrentPhase]),
+					phases[best].Name, bestPressure)
+
+				state.NextPhase = best
+				tm.setSignalInterval(intersection, models.SignalYellow, now)
+			}
+
+		case models.SignalYellow:
+			if elapsed >= sc.YellowTime {
+				tm.setSignalInterval(intersection, models.SignalAllRed, now)
+			}
+
+		case models.SignalAllRed:
+			if elapsed >= sc.AllRedTime {
+				state.CurrentPhase = state.NextPhase
+				state.LastDetectionTime = now
+				tm.setSignalInterval(intersection, models.SignalGreen, now)
+			}
+		}
+
+		tm.applySignalCommands(junctionID, phases[state.CurrentPhase], state.SignalInterval)
+	}
+}
+
+func (tm *TrafficManager) selectMaxPressurePhase(phases []models.SignalPhase, current int) (int, float64) {
+	best := current
+	bestPressure := tm.calculatePhasePressure(phases[current])
+
+	for i, phase := range phases {
+		pressure := tm.calculatePhasePressure(phase)
+		if pressure > bestPressure {
+			best = i
+			bestPressure = pressure
+		}
+	}
+
+	return best, bestPressure
+}
+
+func (tm *TrafficManager) calculatePhasePressure(phase models.SignalPhase) float64 {
+	pressure := 0.0
+	for _, movement := range phase.Movements {
+		pressure += tm.calculateMovementPressure(movement)
+	}
+	return pressure
+}
+
+func (tm *TrafficManager) calculateMovementPressure(movement models.Movement) float64 {
+	upstream := float64(tm.MovementVehicleCounts[movement])
+	upstream += tm.ExpectedUpstreamArrivals(movement, tm.MaxPressure.ForecastHorizon)
+	downstream := tm.EdgeVehicleCounts[tm.getMovementExitEdge(movement.Edge, movement.Direction)]
+
+	return upstream - float64(downstream)
+}
+
+func (tm *TrafficManager) getMaxPressurePhases(junctionID string) []models.SignalPhase {
+	mp := tm.MaxPressure
+	if phases, exists := mp.phases[junctionID]; exists {
+		return phases
+	}
+
+	movements := tm.getJunctionMovements(junctionID)
+	phases := make([]models.SignalPhase, 0)
+
+	for mask := 1; mask < 1<<len(movements); mask++ {
+		if !tm.isCompatibleMovementSet(movements, mask) || !tm.isMaximalMovementSet(movements, mask) {
+			continue
+		}
+
+		phase := models.SignalPhase{MinGreen: mp.MinPhaseTime}
+		names := make([]string, 0)
+		for i, movement := range movements {
+			if mask&(1<<i) != 0 {
+				phase.Movements = append(phase.Movements, movement)
+				names = append(names, movement.Edge+"/"+movement.Direction)
+			}
+		}
+		phase.Name = strings.Join(names, ",")
+
+		phases = append(phases, phase)
+	}
+
+	mp.phases[junctionID] = phases
+	log.Printf("max-pressure %s: built %d compatible phases from %d movements",
+		junctionID, len(phases), len(movements))
+
+	return phases
+}
+
+func (tm *TrafficManager) isCompatibleMovementSet(movements []models.Movement, mask int) bool {
+	for i := range movements {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+
+		for j := i + 1; j < len(movements); j++ {
+			if mask&(1<<j) == 0 {
+				continue
+			}
+
+			a, b := movements[i], movements[j]
+			if !tm.areMovementsCompatible(a.Edge, a.Direction, b.Edge, b.Direction) ||
+				!tm.areMovementsCompatible(b.Edge, b.Direction, a.Edge, a.Direction) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (tm *TrafficManager) isMaximalMovementSet(movements []models.Movement, mask int) bool {
+	for i := range movements {
+		if mask&(1<<i) == 0 && tm.isCompatibleMovementSet(movements, mask|(1<<i)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"sumo/models"
+)
+
+func TestMaxPressureFollowsArrivalForecast(t *testing.T) {
+	tm := NewTrafficManager()
+	tm.SetAlgorithm(AlgorithmMaxPressure)
+	tm.PredictArrivals()
+
+	phases := tm.getMaxPressurePhases(":C2")
+	if best, _ := tm.selectMaxPressurePhase(phases, 0); best != 0 {
+		t.Fatalf("switched to phase %s without any demand", phases[best].Name)
+	}
+
+	var movement models.Movement
+	for _, candidate := range tm.getJunctionMovements(":C2") {
+		if !slices.Contains(phases[0].Movements, candidate) {
+			movement = candidate
+			break
+		}
+	}
+
+	approach := tm.GetApproachForecast(":C2", movement.Edge)
+	length := tm.getEdgeLengths()[movement.Edge]
+	for i, distance := range []float64{20, 40, 60} {
+		approach.Arrivals = append(approach.Arrivals, PredictedArrival{
+			VehicleID: fmt.Sprintf("upstream_%d", i),
+			Direction: movement.Direction,
+			Distance:  length + distance,
+			ETA:       tm.SimulationTime() + distance/10,
+		})
+	}
+
+	best, _ := tm.selectMaxPressurePhase(phases, 0)
+	if !slices.Contains(phases[best].Movements, movement) {
+		t.Errorf("selected phase %s, want a phase serving the forecast %s %s",
+			phases[best].Name, movement.Edge, movement.Direction)
+	}
+}
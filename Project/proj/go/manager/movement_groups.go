@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"log"
+	"sort"
+
+	"sumo/models"
+)
+
+type DivergencePlanner struct {
+	DecisionDistance float64
+	SplitDistance    float64
+
+	Groups   map[string][]*MovementGroup
+	Splits   int
+	Deferred int
+
+	deferred map[string]bool
+}
+
+type MovementGroup struct {
+	Direction  string   `json:"direction"`
+	VehicleIDs []string `json:"vehicles"`
+	Distance   float64  `json:"distance"`
+}
+
+func NewDivergencePlanner() *DivergencePlanner {
+	return &DivergencePlanner{
+		DecisionDistance: 50.0,
+		SplitDistance:    15.0,
+		Groups:           make(map[string][]*MovementGroup),
+		deferred:         make(map[string]bool),
+	}
+}
+
+func (tm *TrafficManager) SplitDivergingPlatoons() {
+	dp := tm.Divergence
+	dp.Groups = make(map[string][]*MovementGroup)
+
+	ids := make([]string, 0, len(tm.Platoons))
+	for id := range tm.Platoons {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for id := range dp.deferred {
+		if _, exists := tm.Platoons[id]; !exists {
+			delete(dp.deferred, id)
+		}
+	}
+
+	for _, platoonID := range ids {
+		platoon := tm.Platoons[platoonID]
+
+		approaching, behind := tm.splitApproachingMembers(platoon)
+		if len(approaching) == 0 {
+			continue
+		}
+
+		groups := tm.groupByMovement(approaching)
+		dp.Groups[platoonID] = groups
+
+		if len(groups) < 2 || platoon.Maneuver != nil {
+			continue
+		}
+
+		index := len(groups[0].VehicleIDs)
+		rearLeader := approaching[index]
+
+		distance := tm.estimateDistanceToIntersection(rearLeader, nil)
+		if distance > dp.DecisionDistance {
+			continue
+		}
+
+		if distance < dp.SplitDistance || !tm.canStopComfortably(rearLeader, distance) {
+			if !dp.deferred[platoonID] {
+				dp.deferred[platoonID] = true
+				dp.Deferred++
+				log.Printf("divergence: %s turning %s is %.1fm from the stop line, crossing %s as %d movement groups",
+					rearLeader.ID, groups[1].Direction, distance, platoonID, len(groups))
+			}
+			continue
+		}
+
+		rear := tm.vehicleIDs(approaching[index:])
+		rear = append(rear, tm.vehicleIDs(behind)...)
+
+		kind := models.ManeuverSplit
+		if len(rear) == 1 {
+			kind = models.ManeuverLeave
+		}
+
+		if tm.requestManeuver(kind, platoonID, "", rear) == nil {
+			continue
+		}
+
+		delete(dp.deferred, platoonID)
+		dp.Splits++
+		log.Printf("divergence: separating %s on %s, %d vehicles going %s ahead of %d going %s",
+			platoonID, rearLeader.Edge, index, groups[0].Direction, len(rear), groups[1].Direction)
+	}
+}
+
+func (tm *TrafficManager) groupByMovement(vehicles []*models.Vehicle) []*MovementGroup {
+	groups := make([]*MovementGroup, 0)
+
+	for _, vehicle := range vehicles {
+		direction := tm.getVehicleDirection(vehicle)
+
+		if n := len(groups); n > 0 && (direction == "" || groups[n-1].Direction == direction) {
+			groups[n-1].VehicleIDs = append(groups[n-1].VehicleIDs, vehicle.ID)
+			continue
+		}
+
+		if n := len(groups); n > 0 && groups[n-1].Direction == "" {
+			groups[n-1].Direction = direction
+			groups[n-1].VehicleIDs = append(groups[n-1].VehicleIDs, vehicle.ID)
+			continue
+		}
+
+		groups = append(groups, &MovementGroup{
+			Direction:  direction,
+			VehicleIDs: []string{vehicle.ID},
+			Distance:   tm.estimateDistanceToIntersection(vehicle, nil),
+		})
+	}
+
+	return groups
+}
+
+func (tm *TrafficManager) sharesNextMovement(vehicles []*models.Vehicle) bool {
+	junctions := tm.getEdgeJunctions()
+	movement := ""
+
+	for _, vehicle := range vehicles {
+		if _, isApproach := junctions[vehicle.Edge]; !isApproach {
+			continue
+		}
+
+		if tm.estimateDistanceToIntersection(vehicle, nil) > tm.Divergence.DecisionDistance {
+			continue
+		}
+
+		direction := tm.getVehicleDirection(vehicle)
+		if direction == "" {
+			continue
+		}
+
+		if movement != "" && direction != movement {
+			return false
+		}
+		movement = direction
+	}
+
+	return true
+}
+
+func (tm *TrafficManager) canExtendPlatoon(platoonID string, extra []*models.Vehicle) bool {
+	platoon, exists := tm.Platoons[platoonID]
+	if !exists {
+		return true
+	}
+
+	vehicles := append(tm.getOrderedPlatoonVehicles(platoon), extra...)
+	return tm.sharesNextMovement(vehicles) && tm.platoonFitsWith(platoonID, extra)
+}
+
+func (tm *TrafficManager) getPlatoonMovementGroups(platoon *models.Platoon) []*MovementGroup {
+	approaching, _ := tm.splitApproachingMembers(platoon)
+	if len(approaching) == 0 {
+		return nil
+	}
+	return tm.groupByMovement(approaching)
+}
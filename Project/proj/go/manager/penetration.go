@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"sumo/models"
+)
+
+type ConnectivityModel struct {
+	PenetrationRate float64
+	Seed            int64
+
+	Connected    int
+	NonConnected int
+}
+
+type ConnectivityStats struct {
+	Vehicles          int
+	AverageSpeed      float64
+	AverageWaitTime   float64
+	AverageTravelTime float64
+}
+
+func NewConnectivityModel() *ConnectivityModel {
+	return &ConnectivityModel{
+		PenetrationRate: 1.0,
+		Seed:            1,
+	}
+}
+
+func (cm *ConnectivityModel) isConnected(id string, data map[string]interface{}) bool {
+	if connected, ok := data["connected"].(bool); ok {
+		return connected
+	}
+
+	if cm.PenetrationRate >= 1.0 {
+		return true
+	}
+	if cm.PenetrationRate <= 0 {
+		return false
+	}
+
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d/%s", cm.Seed, id)
+	draw := float64(hash.Sum64()%1000000) / 1000000.0
+
+	return draw < cm.PenetrationRate
+}
+
+func (tm *TrafficManager) registerConnectivity(vehicle *models.Vehicle, data map[string]interface{}) {
+	cm := tm.Connectivity
+	vehicle.Connected = cm.isConnected(vehicle.ID, data)
+
+	if vehicle.Connected {
+		cm.Connected++
+	} else {
+		cm.NonConnected++
+	}
+}
+
+func (tm *TrafficManager) calculateConnectivityStats(connected bool) ConnectivityStats {
+	stats := ConnectivityStats{}
+	waiting, travelling := 0, 0
+
+	for _, vehicle := range tm.Vehicles {
+		if vehicle.Connected != connected {
+			continue
+		}
+
+		stats.Vehicles++
+		stats.AverageSpeed += vehicle.Speed
+
+		if vehicle.WaitingTime > 0 {
+			stats.AverageWaitTime += float64(vehicle.WaitingTime)
+			waiting++
+		}
+		if vehicle.TravelTime > 0 {
+			stats.AverageTravelTime += vehicle.TravelTime
+			travelling++
+		}
+	}
+
+	if stats.Vehicles > 0 {
+		stats.AverageSpeed /= float64(stats.Vehicles)
+	}
+	if waiting > 0 {
+		stats.AverageWaitTime /= float64(waiting)
+	}
+	if travelling > 0 {
+		stats.AverageTravelTime /= float64(travelling)
+	}
+
+	return stats
+}
+
+func averageConnectivityStats(metrics []BenchmarkMetrics, connected bool) ConnectivityStats {
+	average := ConnectivityStats{}
+	steps := 0
+
+	for _, m := range metrics {
+		stats := m.NonConnected
+		if connected {
+			stats = m.Connected
+		}
+		if stats.Vehicles == 0 {
+			continue
+		}
+
+		average.Vehicles += stats.Vehicles
+		average.AverageSpeed += stats.AverageSpeed
+		average.AverageWaitTime += stats.AverageWaitTime
+		average.AverageTravelTime += stats.AverageTravelTime
+		steps++
+	}
+
+	if steps > 0 {
+		average.Vehicles /= steps
+		average.AverageSpeed /= float64(steps)
+		average.AverageWaitTime /= float64(steps)
+		average.AverageTravelTime /= float64(steps)
+	}
+
+	return average
+}
@@ -0,0 +1,208 @@
+package manager
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+const (
+	AdvisoryCatchUp  = "catch_up"
+	AdvisorySlowDown = "slow_down"
+	AdvisoryOpenGap  = "open_gap"
+)
+
+type FormationPlanner struct {
+	MaxFormationGap       float64
+	MinDistanceToJunction float64
+	MaxPlatoonSize        int
+
+	Advisories map[string]*FormationAdvisory
+}
+
+type FormationAdvisory struct {
+	VehicleID string  `json:"vehicle"`
+	TargetID  string  `json:"target"`
+	Kind      string  `json:"kind"`
+	Speed     float64 `json:"speed"`
+	IssuedAt  float64 `json:"issued_at"`
+}
+
+type formationUnit struct {
+	platoonID string
+	vehicles  []*models.Vehicle
+	obstacle  bool
+}
+
+func NewFormationPlanner() *FormationPlanner {
+	return &FormationPlanner{
+		MaxFormationGap:       80.0,
+		MinDistanceToJunction: 20.0,
+		MaxPlatoonSize:        15,
+		Advisories:            make(map[string]*FormationAdvisory),
+	}
+}
+
+func (u formationUnit) head() *models.Vehicle {
+	return u.vehicles[0]
+}
+
+func (u formationUnit) tail() *models.Vehicle {
+	return u.vehicles[len(u.vehicles)-1]
+}
+
+func (tm *TrafficManager) PlanPlatoonFormation() {
+	fp := tm.Formation
+	now := tm.SimulationTime()
+	previous := fp.Advisories
+	fp.Advisories = make(map[string]*FormationAdvisory)
+
+	for _, units := range tm.collectFormationUnits() {
+		for i := 1; i < len(units); i++ {
+			front, rear := units[i-1], units[i]
+
+			if tm.getVehicleDirection(front.tail()) != tm.getVehicleDirection(rear.head()) {
+				continue
+			}
+
+			if front.obstacle || rear.obstacle {
+				continue
+			}
+
+			if len(front.vehicles)+len(rear.vehicles) > fp.MaxPlatoonSize {
+				continue
+			}
+
+			if !tm.fitsClearanceLimit(append(append([]*models.Vehicle(nil), front.vehicles...), rear.vehicles...)) {
+				continue
+			}
+
+			tm.planUnitMerge(front, rear, now)
+		}
+	}
+
+	for id, advisory := range fp.Advisories {
+		if _, existed := previous[id]; !existed {
+			log.Printf("formation: %s advisory for %s towards %s at %.1f m/s",
+				advisory.Kind, id, advisory.TargetID, advisory.Speed)
+		}
+	}
+}
+
+func (tm *TrafficManager) collectFormationUnits() map[string][]formationUnit {
+	fp := tm.Formation
+	junctions := tm.getEdgeJunctions()
+	lanes := make(map[string][]*models.Vehicle)
+
+	for _, vehicle := range tm.Vehicles {
+		if _, isApproach := junctions[vehicle.Edge]; !isApproach {
+			continue
+		}
+
+		if tm.estimateDistanceToIntersection(vehicle, nil) < fp.MinDistanceToJunction {
+			continue
+		}
+
+		if tm.isPreemptionSuppressed(vehicle) {
+			continue
+		}
+
+		lanes[vehicle.Lane] = append(lanes[vehicle.Lane], vehicle)
+	}
+
+	unitsByLane := make(map[string][]formationUnit)
+
+	for lane, vehicles := range lanes {
+		sort.Slice(vehicles, func(i, j int) bool {
+			return vehicles[i].Pos > vehicles[j].Pos
+		})
+
+		units := make([]formationUnit, 0)
+		for _, vehicle := range vehicles {
+			platoonID := tm.VehicleToPlatoon[vehicle.ID]
+			if n := len(units); n > 0 && platoonID != "" && units[n-1].platoonID == platoonID {
+				units[n-1].vehicles = append(units[n-1].vehicles, vehicle)
+				continue
+			}
+
+			units = append(units, formationUnit{
+				platoonID: platoonID,
+				vehicles:  []*models.Vehicle{vehicle},
+				obstacle:  !vehicle.Connected,
+			})
+		}
+
+		unitsByLane[lane] = units
+	}
+
+	return unitsByLane
+}
+
+func (tm *TrafficManager) planUnitMerge(front, rear formationUnit, now float64) {
+	fp := tm.Formation
+	tail := front.tail()
+	head := rear.head()
+
+	gap := tm.calculateBumperGap(head, tail)
+	if gap > fp.MaxFormationGap {
+		return
+	}
+
+	if gap < tm.PlatoonGapTooClose {
+		tm.issueAdvisory(head, tail, AdvisoryOpenGap, tail.Speed*0.9, now)
+		return
+	}
+
+	if gap <= tm.PlatoonGapClose {
+		return
+	}
+
+	catchUpSpeed := math.Min(math.Max(tail.Speed, 1.0)*tm.CatchupSpeedFactor, tm.MaxPlatoonSpeed)
+	closingSpeed := catchUpSpeed - tail.Speed
+	timeToJunction := tm.estimateDistanceToIntersection(front.head(), nil) / math.Max(front.head().Speed, 1.0)
+
+	if closingSpeed > 0 && (gap-tm.PlatoonGapClose)/closingSpeed <= timeToJunction {
+		tm.issueAdvisory(head, tail, AdvisoryCatchUp, catchUpSpeed, now)
+		return
+	}
+
+	if len(front.vehicles) > len(rear.vehicles) || front.head().Speed < 1.0 {
+		return
+	}
+
+	slowDownSpeed := head.Speed / tm.CatchupSpeedFactor
+	if slowDownSpeed >= front.head().Speed {
+		return
+	}
+
+	tm.issueAdvisory(front.head(), head, AdvisorySlowDown, slowDownSpeed, now)
+}
+
+func (tm *TrafficManager) issueAdvisory(vehicle, target *models.Vehicle, kind string, speed, now float64) {
+	fp := tm.Formation
+	if _, exists := fp.Advisories[vehicle.ID]; exists {
+		return
+	}
+
+	fp.Advisories[vehicle.ID] = &FormationAdvisory{
+		VehicleID: vehicle.ID,
+		TargetID:  target.ID,
+		Kind:      kind,
+		Speed:     speed,
+		IssuedAt:  now,
+	}
+
+	switch kind {
+	case AdvisoryCatchUp:
+		accel := tm.idmAcceleration(vehicle, target, speed, tm.caccTimeGap(vehicle))
+		vehicle.DesiredSpeed = tm.capToCrossingProfile(vehicle, tm.commandSpeed(vehicle, accel, speed))
+	case AdvisorySlowDown, AdvisoryOpenGap:
+		vehicle.DesiredSpeed = math.Min(vehicle.DesiredSpeed, speed)
+	}
+}
+
+func (tm *TrafficManager) GetFormationAdvisories() map[string]*FormationAdvisory {
+	return tm.Formation.Advisories
+}
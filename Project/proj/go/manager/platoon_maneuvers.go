@@ -0,0 +1,449 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type ManeuverController struct {
+	Timeouts           map[string]float64
+	MaxJoinGap         float64
+	LeaveGap           float64
+	JoinSpeedTolerance float64
+	GapOpeningSpeed    float64
+
+	Active    map[string]*models.PlatoonManeuver
+	Completed int
+	Aborted   int
+}
+
+func NewManeuverController() *ManeuverController {
+	return &ManeuverController{
+		Timeouts: map[string]float64{
+			models.ManeuverRequested:  2.0,
+			models.ManeuverGapOpening: 10.0,
+			models.ManeuverClosing:    20.0,
+			models.ManeuverLeaving:    10.0,
+		},
+		MaxJoinGap:         60.0,
+		LeaveGap:           30.0,
+		JoinSpeedTolerance: 1.5,
+		GapOpeningSpeed:    1.0,
+		Active:             make(map[string]*models.PlatoonManeuver),
+	}
+}
+
+func (tm *TrafficManager) requestManeuver(kind, platoonID, mergingPlatoonID string, vehicleIDs []string) *models.PlatoonManeuver {
+	mc := tm.Maneuvers
+	now := tm.SimulationTime()
+
+	for _, id := range []string{platoonID, mergingPlatoonID} {
+		if platoon, exists := tm.Platoons[id]; exists && platoon.Maneuver != nil {
+			return nil
+		}
+	}
+
+	for _, vid := range vehicleIDs {
+		if tm.findVehicleManeuver(vid) != nil {
+			return nil
+		}
+	}
+
+	state := models.ManeuverRequested
+	if kind == models.ManeuverLeave || kind == models.ManeuverSplit {
+		state = models.ManeuverLeaving
+	}
+
+	maneuver := &models.PlatoonManeuver{
+		ID:               fmt.Sprintf("%s_%s_%d", kind, vehicleIDs[0], tm.TimeStep),
+		Kind:             kind,
+		State:            state,
+		PlatoonID:        platoonID,
+		MergingPlatoonID: mergingPlatoonID,
+		VehicleIDs:       append([]string(nil), vehicleIDs...),
+		StartTime:        now,
+		StateStartTime:   now,
+	}
+
+	mc.Active[maneuver.ID] = maneuver
+	for _, id := range []string{platoonID, mergingPlatoonID} {
+		if platoon, exists := tm.Platoons[id]; exists {
+			platoon.Maneuver = maneuver
+		}
+	}
+
+	log.Printf("maneuver %s: %s of %v with %s %s", maneuver.ID, kind, vehicleIDs, platoonID, state)
+
+	return maneuver
+}
+
+func (tm *TrafficManager) findVehicleManeuver(vehicleID string) *models.PlatoonManeuver {
+	for _, maneuver := range tm.Maneuvers.Active {
+		if tm.containsVehicle(maneuver.VehicleIDs, vehicleID) {
+			return maneuver
+		}
+	}
+	return nil
+}
+
+func (tm *TrafficManager) AdvancePlatoonManeuvers() {
+	mc := tm.Maneuvers
+	now := tm.SimulationTime()
+
+	ids := make([]string, 0, len(mc.Active))
+	for id := range mc.Active {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		maneuver := mc.Active[id]
+
+		if timeout, limited := mc.Timeouts[maneuver.State]; limited && now-maneuver.StateStartTime > timeout {
+			if maneuver.State == models.ManeuverLeaving {
+				log.Printf("maneuver %s: leaving timed out after %.1fs, forcing separation", maneuver.ID, timeout)
+				tm.completeLeaveManeuver(maneuver, now)
+			} else {
+				tm.abortManeuver(maneuver, fmt.Sprintf("timed out in %s", maneuver.State), now)
+			}
+			continue
+		}
+
+		switch maneuver.Kind {
+		case models.ManeuverJoin, models.ManeuverMerge:
+			tm.advanceJoinManeuver(maneuver, now)
+		case models.ManeuverLeave, models.ManeuverSplit:
+			tm.advanceLeaveManeuver(maneuver, now)
+		}
+	}
+}
+
+func (tm *TrafficManager) getJoinPair(maneuver *models.PlatoonManeuver) (*models.Vehicle, *models.Vehicle, string) {
+	platoon, exists := tm.Platoons[maneuver.PlatoonID]
+	if !exists {
+		return nil, nil, "platoon no longer exists"
+	}
+
+	headID := maneuver.VehicleIDs[0]
+	if maneuver.Kind == models.ManeuverMerge {
+		merging, exists := tm.Platoons[maneuver.MergingPlatoonID]
+		if !exists {
+			return nil, nil, "merging platoon no longer exists"
+		}
+		headID = merging.LeaderID
+	} else if _, inPlatoon := tm.VehicleToPlatoon[headID]; inPlatoon {
+		return nil, nil, "vehicle already in a platoon"
+	}
+
+	head, exists := tm.Vehicles[headID]
+	if !exists {
+		return nil, nil, "vehicle left the simulation"
+	}
+
+	members := tm.getOrderedPlatoonVehicles(platoon)
+	if len(members) == 0 {
+		return nil, nil, "platoon has no vehicles"
+	}
+	tail := members[len(members)-1]
+
+	switch {
+	case head.Lane != tail.Lane:
+		return nil, nil, "no longer in the platoon lane"
+	case head.Pos >= tail.Pos:
+		return nil, nil, "overtook the platoon tail"
+	case tm.calculateBumperGap(head, tail) > tm.Maneuvers.MaxJoinGap:
+		return nil, nil, "fell too far behind"
+	}
+
+	return head, tail, ""
+}
+
+func (tm *TrafficManager) advanceJoinManeuver(maneuver *models.PlatoonManeuver, now float64) {
+	mc := tm.Maneuvers
+
+	head, tail, reason := tm.getJoinPair(maneuver)
+	if reason != "" {
+		tm.abortManeuver(maneuver, reason, now)
+		return
+	}
+
+	gap := tm.calculateBumperGap(head, tail)
+	tooClose := gap < tm.PlatoonGapTooClose && tail.Speed >= mc.GapOpeningSpeed
+
+	switch maneuver.State {
+	case models.ManeuverRequested:
+		size := len(tm.Platoons[maneuver.PlatoonID].VehicleIDs) + len(maneuver.VehicleIDs)
+		if size > tm.Formation.MaxPlatoonSize {
+			tm.abortManeuver(maneuver, fmt.Sprintf("platoon would exceed %d vehicles", tm.Formation.MaxPlatoonSize), now)
+			return
+		}
+
+		if tooClose {
+			tm.setManeuverState(maneuver, models.ManeuverGapOpening, now)
+		} else {
+			tm.setManeuverState(maneuver, models.ManeuverClosing, now)
+		}
+
+	case models.ManeuverGapOpening:
+		if !tooClose {
+			tm.setManeuverState(maneuver, models.ManeuverClosing, now)
+		}
+
+	case models.ManeuverClosing:
+		if tooClose {
+			tm.setManeuverState(maneuver, models.ManeuverGapOpening, now)
+			return
+		}
+
+		if gap <= tm.PlatoonGapClose && math.Abs(head.Speed-tail.Speed) <= mc.JoinSpeedTolerance {
+			if maneuver.Kind == models.ManeuverMerge {
+				tm.completeMerge(maneuver.PlatoonID, maneuver.MergingPlatoonID)
+			} else {
+				tm.completeJoin(head.ID, maneuver.PlatoonID)
+			}
+			tm.finishManeuver(maneuver, models.ManeuverJoined, now)
+		}
+	}
+}
+
+func (tm *TrafficManager) advanceLeaveManeuver(maneuver *models.PlatoonManeuver, now float64) {
+	platoon, exists := tm.Platoons[maneuver.PlatoonID]
+	if !exists {
+		tm.abortManeuver(maneuver, "platoon no longer exists", now)
+		return
+	}
+
+	leaving := make([]*models.Vehicle, 0, len(maneuver.VehicleIDs))
+	for _, vid := range maneuver.VehicleIDs {
+		if v, exists := tm.Vehicles[vid]; exists && tm.containsVehicle(platoon.VehicleIDs, vid) {
+			leaving = append(leaving, v)
+		}
+	}
+
+	if len(leaving) == 0 {
+		tm.abortManeuver(maneuver, "vehicles no longer in platoon", now)
+		return
+	}
+
+	if tm.isSeparatedFromPlatoon(leaving, platoon) {
+		tm.completeLeaveManeuver(maneuver, now)
+	}
+}
+
+func (tm *TrafficManager) isSeparatedFromPlatoon(leaving []*models.Vehicle, platoon *models.Platoon) bool {
+	front := leaving[0]
+	for _, v := range leaving {
+		if v.Pos > front.Pos {
+			front = v
+		}
+	}
+
+	if front.LeaderID != "" && tm.VehicleToPlatoon[front.LeaderID] != platoon.ID {
+		return true
+	}
+
+	for _, v := range tm.getOrderedPlatoonVehicles(platoon) {
+		if tm.containsVehicle(tm.vehicleIDs(leaving), v.ID) {
+			continue
+		}
+
+		if v.Edge != front.Edge || v.Lane != front.Lane || v.Pos <= front.Pos {
+			continue
+		}
+
+		if tm.calculateBumperGap(front, v) < tm.Maneuvers.LeaveGap {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (tm *TrafficManager) vehicleIDs(vehicles []*models.Vehicle) []string {
+	ids := make([]string, 0, len(vehicles))
+	for _, v := range vehicles {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func (tm *TrafficManager) completeLeaveManeuver(maneuver *models.PlatoonManeuver, now float64) {
+	platoon, exists := tm.Platoons[maneuver.PlatoonID]
+	if !exists {
+		tm.abortManeuver(maneuver, "platoon no longer exists", now)
+		return
+	}
+
+	if maneuver.Kind == models.ManeuverSplit {
+		if !tm.completeSplit(platoon, maneuver.VehicleIDs) {
+			tm.abortManeuver(maneuver, "split no longer needed", now)
+			return
+		}
+	} else {
+		tm.RemoveVehicleFromPlatoon(maneuver.VehicleIDs[0], maneuver.PlatoonID)
+		delete(tm.VehicleToPlatoon, maneuver.VehicleIDs[0])
+	}
+
+	tm.finishManeuver(maneuver, models.ManeuverDissolved, now)
+}
+
+func (tm *TrafficManager) setManeuverState(maneuver *models.PlatoonManeuver, state string, now float64) {
+	log.Printf("maneuver %s: %s -> %s after %.1fs", maneuver.ID, maneuver.State, state, now-maneuver.StateStartTime)
+
+	maneuver.State = state
+	maneuver.StateStartTime = now
+}
+
+func (tm *TrafficManager) abortManeuver(maneuver *models.PlatoonManeuver, reason string, now float64) {
+	maneuver.AbortReason = reason
+	log.Printf("maneuver %s: aborted in %s, %s", maneuver.ID, maneuver.State, reason)
+
+	tm.Maneuvers.Aborted++
+	tm.finishManeuver(maneuver, models.ManeuverAborted, now)
+}
+
+func (tm *TrafficManager) finishManeuver(maneuver *models.PlatoonManeuver, state string, now float64) {
+	mc := tm.Maneuvers
+
+	if state != models.ManeuverAborted {
+		mc.Completed++
+		tm.setManeuverState(maneuver, state, now)
+	} else {
+		maneuver.State = state
+		maneuver.StateStartTime = now
+	}
+
+	for _, platoon := range tm.Platoons {
+		if platoon.Maneuver == maneuver {
+			platoon.Maneuver = nil
+		}
+	}
+
+	delete(mc.Active, maneuver.ID)
+}
+
+func (tm *TrafficManager) applyManeuverSpeeds() {
+	for _, maneuver := range tm.Maneuvers.Active {
+		switch maneuver.State {
+		case models.ManeuverGapOpening, models.ManeuverClosing:
+			head, tail, reason := tm.getJoinPair(maneuver)
+			if reason != "" {
+				continue
+			}
+
+			if maneuver.State == models.ManeuverGapOpening {
+				head.DesiredSpeed = math.Min(head.DesiredSpeed, tail.Speed*0.9)
+				continue
+			}
+
+			catchUpSpeed := math.Min(math.Max(tail.Speed, 1.0)*tm.CatchupSpeedFactor, tm.MaxPlatoonSpeed)
+			accel := tm.idmAcceleration(head, tail, catchUpSpeed, tm.caccTimeGap(head))
+			speed := tm.capToCrossingProfile(head, tm.commandSpeed(head, accel, catchUpSpeed))
+
+			if tm.estimateDistanceToIntersection(head, nil) < tm.Formation.MinDistanceToJunction {
+				head.DesiredSpeed = math.Min(head.DesiredSpeed, speed)
+			} else {
+				head.DesiredSpeed = speed
+			}
+
+		case models.ManeuverLeaving:
+			vehicle := tm.findSeparatingFront(maneuver)
+			if vehicle == nil {
+				continue
+			}
+
+			if front, exists := tm.Vehicles[vehicle.LeaderID]; exists && tm.VehicleToPlatoon[front.ID] == maneuver.PlatoonID {
+				vehicle.DesiredSpeed = math.Min(vehicle.DesiredSpeed, front.Speed*0.9)
+			}
+		}
+	}
+}
+
+func (tm *TrafficManager) findSeparatingFront(maneuver *models.PlatoonManeuver) *models.Vehicle {
+	var front *models.Vehicle
+	for _, vid := range maneuver.VehicleIDs {
+		if v, exists := tm.Vehicles[vid]; exists && (front == nil || v.Pos > front.Pos) {
+			front = v
+		}
+	}
+	return front
+}
+
+func (tm *TrafficManager) completeSplit(platoon *models.Platoon, separatingIDs []string) bool {
+	vehiclesOnEdge := make([]*models.Vehicle, 0)
+	vehiclesNotOnEdge := make([]*models.Vehicle, 0)
+
+	for _, vid := range platoon.VehicleIDs {
+		v, exists := tm.Vehicles[vid]
+		if !exists {
+			continue
+		}
+
+		if tm.containsVehicle(separatingIDs, vid) {
+			vehiclesOnEdge = append(vehiclesOnEdge, v)
+		} else {
+			vehiclesNotOnEdge = append(vehiclesNotOnEdge, v)
+		}
+	}
+
+	if len(vehiclesOnEdge) < 2 || len(vehiclesNotOnEdge) < 1 {
+		return false
+	}
+
+	newLeader := vehiclesOnEdge[0]
+	for _, v := range vehiclesOnEdge {
+		if v.Pos > newLeader.Pos {
+			newLeader = v
+		}
+	}
+
+	edgeID := newLeader.Edge
+	newPlatoonID := fmt.Sprintf("p_%s_%s_%d", edgeID, newLeader.ID, tm.TimeStep)
+
+	newPlatoon := &models.Platoon{
+		ID:         newPlatoonID,
+		VehicleIDs: make([]string, 0, len(vehiclesOnEdge)),
+		LeaderID:   newLeader.ID,
+		Edge:       edgeID,
+		Lane:       newLeader.Lane,
+	}
+
+	for _, v := range vehiclesOnEdge {
+		newPlatoon.VehicleIDs = append(newPlatoon.VehicleIDs, v.ID)
+
+		if v.ID == newLeader.ID {
+			v.IsLeader = true
+		} else {
+			v.IsLeader = false
+		}
+
+		v.PlatoonID = newPlatoonID
+		tm.VehicleToPlatoon[v.ID] = newPlatoonID
+	}
+
+	if len(newPlatoon.VehicleIDs) > 0 {
+		tm.Platoons[newPlatoonID] = newPlatoon
+	}
+
+	remainingIDs := make([]string, 0, len(vehiclesNotOnEdge))
+	for _, v := range vehiclesNotOnEdge {
+		remainingIDs = append(remainingIDs, v.ID)
+	}
+
+	if len(remainingIDs) < 2 {
+		for _, v := range vehiclesNotOnEdge {
+			v.PlatoonID = ""
+			v.IsLeader = false
+			delete(tm.VehicleToPlatoon, v.ID)
+		}
+		delete(tm.Platoons, platoon.ID)
+	} else {
+		platoon.VehicleIDs = remainingIDs
+	}
+
+	return true
+}
@@ -2,7 +2,9 @@ package manager
 
 import (
 	"fmt"
+	"maps"
 	"math"
+	"slices"
 
 	"sumo/models"
 )
@@ -13,6 +15,7 @@ func (tm *TrafficManager) UpdatePlatoons() {
 	tm.cleanupPlatoons()
 	tm.checkEdgeTransitions()
 	tm.consolidatePlatoons()
+	tm.AdvancePlatoonManeuvers()
 }
 
 func (tm *TrafficManager) updateLeaderRelationships() {
@@ -29,7 +32,8 @@ func (tm *TrafficManager) updateLeaderRelationships() {
 }
 
 func (tm *TrafficManager) formAndUpdatePlatoons() {
-	for id, v := range tm.Vehicles {
+	for _, id := range slices.Sorted(maps.Keys(tm.Vehicles)) {
+		v := tm.Vehicles[id]
 		if v.LeaderID == "" {
 			continue
 		}
@@ -39,15 +43,43 @@ func (tm *TrafficManager) formAndUpdatePlatoons() {
 			continue
 		}
 
+		if tm.isPreemptionSuppressed(v) || tm.isPreemptionSuppressed(leader) || !v.Connected || !leader.Connected {
+			continue
+		}
+
 		if leaderPlatoonID, exists := tm.VehicleToPlatoon[leader.ID]; exists {
 			if currentPlatoonID, ok := tm.VehicleToPlatoon[id]; !ok || currentPlatoonID != leaderPlatoonID {
 				if ok && currentPlatoonID != leaderPlatoonID {
-					tm.RemoveVehicleFromPlatoon(id, currentPlatoonID)
+					if v.IsLeader {
+						tm.mergePlatoons(leaderPlatoonID, currentPlatoonID)
+					} else {
+						tm.RequestLeavePlatoon(id, currentPlatoonID)
+					}
+					continue
 				}
 
 				tm.AddVehicleToPlatoon(id, leaderPlatoonID)
 			}
 		} else {
+			currentPlatoonID, inPlatoon := tm.VehicleToPlatoon[id]
+			if inPlatoon && !v.IsLeader {
+				continue
+			}
+
+			if tm.findVehicleManeuver(id) != nil || tm.findVehicleManeuver(leader.ID) != nil {
+				continue
+			}
+
+			followers := []*models.Vehicle{v}
+			if inPlatoon {
+				followers = tm.getOrderedPlatoonVehicles(tm.Platoons[currentPlatoonID])
+			}
+
+			pair := append([]*models.Vehicle{leader}, followers...)
+			if !tm.fitsClearanceLimit(pair) || !tm.sharesNextMovement(pair) {
+				continue
+			}
+
 			gap := leader.Pos - v.Pos
 
 			if gap <= 25.0 && !isEdgeTransition(leader.Edge, v.Edge) {
@@ -55,19 +87,28 @@ func (tm *TrafficManager) formAndUpdatePlatoons() {
 
 				tm.Platoons[platoonID] = &models.Platoon{
 					ID:         platoonID,
-					VehicleIDs: []string{leader.ID, id},
+					VehicleIDs: []string{leader.ID},
 					LeaderID:   leader.ID,
 					Edge:       leader.Edge,
 					Lane:       leader.Lane,
 				}
 
+				var maneuver *models.PlatoonManeuver
+				if inPlatoon {
+					maneuver = tm.requestManeuver(models.ManeuverMerge, platoonID, currentPlatoonID, tm.vehicleIDs(followers))
+				} else {
+					maneuver = tm.requestManeuver(models.ManeuverJoin, platoonID, "", []string{id})
+				}
+
+				if maneuver == nil {
+					delete(tm.Platoons, platoonID)
+					continue
+				}
+
 				tm.VehicleToPlatoon[leader.ID] = platoonID
-				tm.VehicleToPlatoon[id] = platoonID
 
 				leader.PlatoonID = platoonID
 				leader.IsLeader = true
-				v.PlatoonID = platoonID
-				v.IsLeader = false
 			}
 		}
 	}
@@ -105,7 +146,7 @@ func isEdgeTransition(edge1, edge2 string) bool {
 
 func (tm *TrafficManager) cleanupPlatoons() {
 	for platoonID, platoon := range tm.Platoons {
-		if len(platoon.VehicleIDs) <= 1 {
+		if len(platoon.VehicleIDs) <= 1 && platoon.Maneuver == nil {
 			for _, vid := range platoon.VehicleIDs {
 				if vehicle, exists := tm.Vehicles[vid]; exists {
 					vehicle.PlatoonID = ""
@@ -134,103 +175,34 @@ func (tm *TrafficManager) checkEdgeTransitions() {
 		"down_incoming":  true,
 	}
 
-	for platoonID, platoon := range tm.Platoons {
-		vehiclesByEdge := make(map[string][]*models.Vehicle)
-
-		for _, vid := range platoon.VehicleIDs {
-			v, exists := tm.Vehicles[vid]
-			if !exists {
-				continue
-			}
-
-			vehiclesByEdge[v.Edge] = append(vehiclesByEdge[v.Edge], v)
-		}
-
-		if len(vehiclesByEdge) <= 1 {
+	for _, platoonID := range slices.Sorted(maps.Keys(tm.Platoons)) {
+		platoon := tm.Platoons[platoonID]
+		if platoon.Maneuver != nil {
 			continue
 		}
 
-		splitNeeded := false
-
-		for edge1, _ := range vehiclesByEdge {
-			if !leavingEdges[edge1] && !incomingEdges[edge1] {
-				continue
-			}
-
-			for edge2, _ := range vehiclesByEdge {
-				if edge1 == edge2 {
-					continue
-				}
-
-				if (leavingEdges[edge1] && incomingEdges[edge2]) ||
-					(leavingEdges[edge2] && incomingEdges[edge1]) {
-					splitNeeded = true
-					break
-				}
-			}
+		crossed := false
+		rear := make([]string, 0)
 
-			if splitNeeded {
-				break
+		for _, v := range tm.getOrderedPlatoonVehicles(platoon) {
+			if leavingEdges[v.Edge] {
+				crossed = true
+			} else if incomingEdges[v.Edge] {
+				rear = append(rear, v.ID)
 			}
 		}
 
-		if splitNeeded {
-			for edge, edgeVehicles := range vehiclesByEdge {
-				if len(edgeVehicles) >= 2 {
-					if leavingEdges[edge] {
-						tm.createNewPlatoonFromVehicles(edgeVehicles, edge)
-					}
-				}
-			}
-
-			delete(tm.Platoons, platoonID)
-
-			for _, vid := range platoon.VehicleIDs {
-				if _, exists := tm.VehicleToPlatoon[vid]; exists {
-					delete(tm.VehicleToPlatoon, vid)
-				}
-			}
+		if !crossed || len(rear) == 0 {
+			continue
 		}
-	}
-}
-
-func (tm *TrafficManager) createNewPlatoonFromVehicles(vehicles []*models.Vehicle, edge string) {
-	if len(vehicles) < 2 {
-		return
-	}
-
-	sortedVehicles := make([]*models.Vehicle, len(vehicles))
-	copy(sortedVehicles, vehicles)
 
-	for i := 0; i < len(sortedVehicles)-1; i++ {
-		for j := 0; j < len(sortedVehicles)-i-1; j++ {
-			if sortedVehicles[j].Pos < sortedVehicles[j+1].Pos {
-				sortedVehicles[j], sortedVehicles[j+1] = sortedVehicles[j+1], sortedVehicles[j]
-			}
+		kind := models.ManeuverSplit
+		if len(rear) == 1 {
+			kind = models.ManeuverLeave
 		}
-	}
-
-	newLeader := sortedVehicles[0]
-	newPlatoonID := fmt.Sprintf("p_%s_%s_%d", edge, newLeader.ID, tm.TimeStep)
-
-	newPlatoon := &models.Platoon{
-		ID:         newPlatoonID,
-		VehicleIDs: make([]string, 0, len(sortedVehicles)),
-		LeaderID:   newLeader.ID,
-		Edge:       edge,
-		Lane:       newLeader.Lane,
-	}
-
-	for _, v := range sortedVehicles {
-		newPlatoon.VehicleIDs = append(newPlatoon.VehicleIDs, v.ID)
 
-		v.PlatoonID = newPlatoonID
-		v.IsLeader = (v.ID == newLeader.ID)
-
-		tm.VehicleToPlatoon[v.ID] = newPlatoonID
+		tm.requestManeuver(kind, platoonID, "", rear)
 	}
-
-	tm.Platoons[newPlatoonID] = newPlatoon
 }
 
 func (tm *TrafficManager) consolidatePlatoons() {
@@ -292,6 +264,19 @@ func (tm *TrafficManager) FindNearbyPlatoonLeaders(vehicle *models.Vehicle) []st
 }
 
 func (tm *TrafficManager) mergePlatoons(leadingPlatoonID, trailingPlatoonID string) {
+	trailingPlatoon, exists := tm.Platoons[trailingPlatoonID]
+	if _, leadingExists := tm.Platoons[leadingPlatoonID]; !leadingExists || !exists {
+		return
+	}
+
+	if !tm.canExtendPlatoon(leadingPlatoonID, tm.getOrderedPlatoonVehicles(trailingPlatoon)) {
+		return
+	}
+
+	tm.requestManeuver(models.ManeuverMerge, leadingPlatoonID, trailingPlatoonID, trailingPlatoon.VehicleIDs)
+}
+
+func (tm *TrafficManager) completeMerge(leadingPlatoonID, trailingPlatoonID string) {
 	leadingPlatoon, exists1 := tm.Platoons[leadingPlatoonID]
 	trailingPlatoon, exists2 := tm.Platoons[trailingPlatoonID]
 
@@ -341,6 +326,24 @@ func (tm *TrafficManager) AddVehicleToPlatoon(vehicleID, platoonID string) {
 		return
 	}
 
+	vehicle, exists := tm.Vehicles[vehicleID]
+	if !exists || tm.containsVehicle(platoon.VehicleIDs, vehicleID) {
+		return
+	}
+
+	if !tm.canExtendPlatoon(platoonID, []*models.Vehicle{vehicle}) {
+		return
+	}
+
+	tm.requestManeuver(models.ManeuverJoin, platoonID, "", []string{vehicleID})
+}
+
+func (tm *TrafficManager) completeJoin(vehicleID, platoonID string) {
+	platoon, exists := tm.Platoons[platoonID]
+	if !exists {
+		return
+	}
+
 	vehicle, exists := tm.Vehicles[vehicleID]
 	if !exists {
 		return
@@ -356,6 +359,15 @@ func (tm *TrafficManager) AddVehicleToPlatoon(vehicleID, platoonID string) {
 	tm.VehicleToPlatoon[vehicleID] = platoonID
 }
 
+func (tm *TrafficManager) RequestLeavePlatoon(vehicleID, platoonID string) {
+	platoon, exists := tm.Platoons[platoonID]
+	if !exists || !tm.containsVehicle(platoon.VehicleIDs, vehicleID) {
+		return
+	}
+
+	tm.requestManeuver(models.ManeuverLeave, platoonID, "", []string{vehicleID})
+}
+
 func (tm *TrafficManager) RemoveVehicleFromPlatoon(vehicleID, platoonID string) {
 	platoon, exists := tm.Platoons[platoonID]
 	if !exists {
@@ -392,7 +404,22 @@ func (tm *TrafficManager) GetPlatoonsForVisualization() map[string]map[string]in
 			"vehicles": platoon.VehicleIDs,
 			"edge":     platoon.Edge,
 			"lane":     platoon.Lane,
+			"maneuver": nil,
 		}
+
+		if metrics, exists := tm.StringStability.Metrics[id]; exists {
+			platoonData["string_stability"] = metrics
+		}
+
+		if m := platoon.Maneuver; m != nil {
+			platoonData["maneuver"] = map[string]interface{}{
+				"id":       m.ID,
+				"kind":     m.Kind,
+				"state":    m.State,
+				"vehicles": m.VehicleIDs,
+			}
+		}
+
 		platoons[id] = platoonData
 	}
 
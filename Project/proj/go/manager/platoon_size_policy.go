@@ -0,0 +1,257 @@
+package manager
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type PlatoonSizePolicy struct {
+	MaxClearanceTime float64
+	MinClearanceTime float64
+	DemandPenalty    float64
+	HaltingSpeed     float64
+	StartupLoss      float64
+	MinCrossingSpeed float64
+	SplitDistance    float64
+
+	Limits   map[string]*PlatoonClearance
+	Splits   int
+	Deferred int
+
+	deferred map[string]bool
+}
+
+type PlatoonClearance struct {
+	Size          int     `json:"size"`
+	Limit         int     `json:"limit"`
+	ClearanceTime float64 `json:"clearance_time"`
+	Budget        float64 `json:"budget"`
+	Demand        int     `json:"demand"`
+}
+
+func NewPlatoonSizePolicy() *PlatoonSizePolicy {
+	return &PlatoonSizePolicy{
+		MaxClearanceTime: 20.0,
+		MinClearanceTime: 6.0,
+		DemandPenalty:    1.0,
+		HaltingSpeed:     0.5,
+		StartupLoss:      2.0,
+		MinCrossingSpeed: 5.0,
+		SplitDistance:    40.0,
+		Limits:           make(map[string]*PlatoonClearance),
+		deferred:         make(map[string]bool),
+	}
+}
+
+func (tm *TrafficManager) EnforcePlatoonSizePolicy() {
+	sp := tm.SizePolicy
+	sp.Limits = make(map[string]*PlatoonClearance)
+
+	ids := make([]string, 0, len(tm.Platoons))
+	for id := range tm.Platoons {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for id := range sp.deferred {
+		if _, exists := tm.Platoons[id]; !exists {
+			delete(sp.deferred, id)
+		}
+	}
+
+	for _, platoonID := range ids {
+		platoon := tm.Platoons[platoonID]
+
+		approaching, behind := tm.splitApproachingMembers(platoon)
+		if len(approaching) == 0 {
+			continue
+		}
+
+		clearance := tm.evaluatePlatoonClearance(approaching)
+		clearance.Size = len(approaching) + len(behind)
+		if clearance.Limit >= len(approaching) {
+			clearance.Limit = clearance.Size
+		}
+		sp.Limits[platoonID] = clearance
+
+		if clearance.Limit >= clearance.Size || platoon.Maneuver != nil {
+			continue
+		}
+
+		index := tm.findSafeSplitIndex(approaching, clearance.Limit)
+		if index < 0 {
+			if !sp.deferred[platoonID] {
+				sp.deferred[platoonID] = true
+				sp.Deferred++
+				log.Printf("platoon size: deferring split of %s, %d vehicles need %.1fs to clear with budget %.1fs but no safe gap upstream",
+					platoonID, clearance.Size, clearance.ClearanceTime, clearance.Budget)
+			}
+			continue
+		}
+
+		rear := tm.vehicleIDs(approaching[index:])
+		rear = append(rear, tm.vehicleIDs(behind)...)
+
+		var maneuver *models.PlatoonManeuver
+		if len(rear) == 1 {
+			maneuver = tm.requestManeuver(models.ManeuverLeave, platoonID, "", rear)
+		} else {
+			maneuver = tm.requestManeuver(models.ManeuverSplit, platoonID, "", rear)
+		}
+		if maneuver == nil {
+			continue
+		}
+
+		delete(sp.deferred, platoonID)
+		sp.Splits++
+		log.Printf("platoon size: splitting %s after %d of %d vehicles, %.1fs to clear %s with budget %.1fs and %d waiting conflicting vehicles",
+			platoonID, index, clearance.Size, clearance.ClearanceTime, approaching[0].Edge, clearance.Budget, clearance.Demand)
+	}
+}
+
+func (tm *TrafficManager) splitApproachingMembers(platoon *models.Platoon) ([]*models.Vehicle, []*models.Vehicle) {
+	leader, exists := tm.Vehicles[platoon.LeaderID]
+	if !exists {
+		return nil, nil
+	}
+	if _, isApproach := tm.getEdgeJunctions()[leader.Edge]; !isApproach {
+		return nil, nil
+	}
+
+	approaching := make([]*models.Vehicle, 0, len(platoon.VehicleIDs))
+	behind := make([]*models.Vehicle, 0)
+	for _, v := range tm.getOrderedPlatoonVehicles(platoon) {
+		if v.Edge == leader.Edge && v.Lane == leader.Lane {
+			approaching = append(approaching, v)
+		} else {
+			behind = append(behind, v)
+		}
+	}
+
+	if len(approaching) == 0 || approaching[0].ID != leader.ID {
+		return nil, nil
+	}
+
+	return approaching, behind
+}
+
+func (tm *TrafficManager) evaluatePlatoonClearance(vehicles []*models.Vehicle) *PlatoonClearance {
+	sp := tm.SizePolicy
+	leader := vehicles[0]
+	direction := tm.getVehicleDirection(leader)
+
+	demand := tm.countConflictingDemand(leader.Edge, direction)
+	clearance := &PlatoonClearance{
+		Limit:  len(vehicles),
+		Budget: math.Max(sp.MinClearanceTime, sp.MaxClearanceTime-sp.DemandPenalty*float64(demand)),
+		Demand: demand,
+	}
+
+	pathLen := pathLength(tm.getMovementPath(leader.Edge, direction))
+	speedLimit := tm.getMovementSpeedLimit(direction)
+
+	speedSum := 0.0
+	for k, member := range vehicles {
+		speedSum += member.Speed
+
+		crossingSpeed := math.Min(speedLimit, math.Max(speedSum/float64(k+1), sp.MinCrossingSpeed))
+		length := leader.Pos - member.Pos + tm.getVehicleType(member).Length
+
+		clearanceTime := (length + pathLen) / crossingSpeed
+		if leader.Speed < sp.HaltingSpeed {
+			clearanceTime += sp.StartupLoss
+		}
+
+		if k > 0 && clearanceTime > clearance.Budget {
+			clearance.Limit = k
+			break
+		}
+		clearance.ClearanceTime = clearanceTime
+	}
+
+	return clearance
+}
+
+func (tm *TrafficManager) countConflictingDemand(edge, direction string) int {
+	junctions := tm.getEdgeJunctions()
+	demand := 0
+
+	for _, vehicle := range tm.Vehicles {
+		if vehicle.Edge == edge || junctions[vehicle.Edge] != junctions[edge] {
+			continue
+		}
+
+		if vehicle.Speed >= tm.SizePolicy.HaltingSpeed {
+			continue
+		}
+
+		distance := tm.estimateDistanceToIntersection(vehicle, nil)
+		if distance < 0 || distance > tm.DetectionDistance {
+			continue
+		}
+
+		if !tm.areMovementsCompatible(edge, direction, vehicle.Edge, tm.getVehicleDirection(vehicle)) {
+			demand++
+		}
+	}
+
+	return demand
+}
+
+func (tm *TrafficManager) findSafeSplitIndex(vehicles []*models.Vehicle, limit int) int {
+	sp := tm.SizePolicy
+	best, bestGap := -1, -1.0
+
+	for index := limit; index >= 1 && index >= (limit+1)/2; index-- {
+		rearLeader := vehicles[index]
+
+		distance := tm.estimateDistanceToIntersection(rearLeader, nil)
+		if distance < sp.SplitDistance || !tm.canStopComfortably(rearLeader, distance) {
+			continue
+		}
+
+		if gap := tm.calculateBumperGap(rearLeader, vehicles[index-1]); gap > bestGap {
+			best, bestGap = index, gap
+		}
+	}
+
+	return best
+}
+
+func (tm *TrafficManager) fitsClearanceLimit(vehicles []*models.Vehicle) bool {
+	if len(vehicles) < 2 {
+		return true
+	}
+
+	ordered := append([]*models.Vehicle(nil), vehicles...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Pos > ordered[j].Pos
+	})
+
+	leader := ordered[0]
+	if _, isApproach := tm.getEdgeJunctions()[leader.Edge]; !isApproach {
+		return true
+	}
+
+	approaching := make([]*models.Vehicle, 0, len(ordered))
+	for _, v := range ordered {
+		if v.Edge == leader.Edge && v.Lane == leader.Lane {
+			approaching = append(approaching, v)
+		}
+	}
+
+	return tm.evaluatePlatoonClearance(approaching).Limit >= len(approaching)
+}
+
+func (tm *TrafficManager) platoonFitsWith(platoonID string, extra []*models.Vehicle) bool {
+	platoon, exists := tm.Platoons[platoonID]
+	if !exists {
+		return true
+	}
+
+	vehicles := append(tm.getOrderedPlatoonVehicles(platoon), extra...)
+	return tm.fitsClearanceLimit(vehicles)
+}
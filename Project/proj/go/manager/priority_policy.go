@@ -0,0 +1,279 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"sumo/models"
+)
+
+const (
+	FeatureSize       = "size"
+	FeatureWait       = "wait"
+	FeatureClass      = "class"
+	FeatureQueue      = "queue"
+	FeatureDownstream = "downstream"
+)
+
+type PriorityPolicy struct {
+	Name           string            `json:"name"`
+	Features       []PriorityFeature `json:"features"`
+	PriorityWindow float64           `json:"priority_window"`
+	PassGap        float64           `json:"pass_gap"`
+	MaxLeaderSpeed float64           `json:"max_leader_speed"`
+	ForcedMinSize  int               `json:"forced_min_size"`
+	ForcedMinWait  int               `json:"forced_min_wait"`
+}
+
+type PriorityFeature struct {
+	Name       string              `json:"name"`
+	Weight     float64             `json:"weight"`
+	Thresholds []PriorityThreshold `json:"thresholds,omitempty"`
+}
+
+type PriorityThreshold struct {
+	Above float64 `json:"above"`
+	Bonus float64 `json:"bonus"`
+}
+
+type PriorityScore struct {
+	Total     float64            `json:"total"`
+	Values    map[string]float64 `json:"values"`
+	Breakdown map[string]float64 `json:"breakdown"`
+}
+
+type PriorityGrant struct {
+	PlatoonID      string        `json:"platoon"`
+	IntersectionID string        `json:"intersection"`
+	Edge           string        `json:"edge"`
+	Policy         string        `json:"policy"`
+	Forced         bool          `json:"forced"`
+	Time           float64       `json:"time"`
+	Until          time.Time     `json:"until"`
+	Score          PriorityScore `json:"score"`
+}
+
+func DefaultPriorityPolicy() *PriorityPolicy {
+	return &PriorityPolicy{
+		Name: "default",
+		Features: []PriorityFeature{
+			{Name: FeatureSize, Thresholds: []PriorityThreshold{{Above: 2, Bonus: 75}, {Above: 4, Bonus: 150}}},
+			{Name: FeatureWait, Weight: 10, Thresholds: []PriorityThreshold{{Above: 15, Bonus: 75}, {Above: 30, Bonus: 150}, {Above: 60, Bonus: 300}}},
+			{Name: FeatureClass, Weight: 20},
+			{Name: FeatureQueue},
+			{Name: FeatureDownstream},
+		},
+		PriorityWindow: 15.0,
+		PassGap:        3.0,
+		MaxLeaderSpeed: 3.0,
+		ForcedMinSize:  5,
+		ForcedMinWait:  60,
+	}
+}
+
+func LoadPriorityPolicy(path string) (*PriorityPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read priority policy: %w", err)
+	}
+
+	return ParsePriorityPolicy(data)
+}
+
+const PriorityPolicyDir = "config"
+
+var priorityPolicyName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func LoadNamedPriorityPolicy(name string) (*PriorityPolicy, error) {
+	if !priorityPolicyName.MatchString(name) {
+		return nil, fmt.Errorf("invalid priority policy name %q", name)
+	}
+	return LoadPriorityPolicy(filepath.Join(PriorityPolicyDir, name+".json"))
+}
+
+func ParsePriorityPolicy(data []byte) (*PriorityPolicy, error) {
+	policy := DefaultPriorityPolicy()
+	defaults := policy.Features
+	policy.Features = nil
+
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse priority policy: %w", err)
+	}
+	if policy.Features == nil {
+		policy.Features = defaults
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (p *PriorityPolicy) Validate() error {
+	known := map[string]bool{
+		FeatureSize:       true,
+		FeatureWait:       true,
+		FeatureClass:      true,
+		FeatureQueue:      true,
+		FeatureDownstream: true,
+	}
+
+	seen := make(map[string]bool)
+	for _, feature := range p.Features {
+		if !known[feature.Name] {
+			return fmt.Errorf("unknown priority feature %q", feature.Name)
+		}
+		if seen[feature.Name] {
+			return fmt.Errorf("duplicate priority feature %q", feature.Name)
+		}
+		seen[feature.Name] = true
+	}
+
+	if p.PriorityWindow <= 0 {
+		return fmt.Errorf("priority window must be positive, got %.1f", p.PriorityWindow)
+	}
+	if p.PassGap < 0 {
+		return fmt.Errorf("pass gap must not be negative, got %.1f", p.PassGap)
+	}
+
+	return nil
+}
+
+func (p *PriorityPolicy) priorityWindow() time.Duration {
+	return time.Duration(p.PriorityWindow * float64(time.Second))
+}
+
+func (f PriorityFeature) score(value float64) float64 {
+	score := f.Weight * value
+
+	bonus, matched := 0.0, false
+	for _, threshold := range f.Thresholds {
+		if value > threshold.Above && (!matched || threshold.Bonus > bonus) {
+			bonus, matched = threshold.Bonus, true
+		}
+	}
+
+	return score + bonus
+}
+
+func (tm *TrafficManager) SetPriorityPolicy(policy *PriorityPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	previous := tm.PriorityPolicy.Name
+	tm.PriorityPolicy = policy
+	log.Printf("priority policy changed from %s to %s", previous, policy.Name)
+
+	return nil
+}
+
+func (tm *TrafficManager) scorePlatoonPriority(platoon *models.Platoon, edge string,
+	vehiclesByEdge map[string][]*models.Vehicle) PriorityScore {
+
+	result := PriorityScore{
+		Values:    make(map[string]float64),
+		Breakdown: make(map[string]float64),
+	}
+
+	for _, feature := range tm.PriorityPolicy.Features {
+		value := tm.priorityFeatureValue(feature.Name, platoon, edge, vehiclesByEdge)
+		score := feature.score(value)
+
+		result.Values[feature.Name] = value
+		result.Breakdown[feature.Name] = score
+		result.Total += score
+	}
+
+	return result
+}
+
+func (tm *TrafficManager) priorityFeatureValue(name string, platoon *models.Platoon, edge string,
+	vehiclesByEdge map[string][]*models.Vehicle) float64 {
+
+	switch name {
+	case FeatureSize:
+		return float64(len(platoon.VehicleIDs))
+	case FeatureWait:
+		return float64(platoon.IntersectionWaitTime)
+	case FeatureClass:
+		_, weighted := tm.calculatePlatoonOccupancy(platoon)
+		return weighted
+	case FeatureQueue:
+		return float64(len(vehiclesByEdge[edge]))
+	case FeatureDownstream:
+		leader, exists := tm.Vehicles[platoon.LeaderID]
+		if !exists {
+			return 0
+		}
+		return tm.calculateDownstreamSpace(tm.getMovementExitEdge(edge, tm.getVehicleDirection(leader)))
+	}
+
+	return 0
+}
+
+func (tm *TrafficManager) calculateDownstreamSpace(exitEdge string) float64 {
+	length, exists := tm.getEdgeLengths()[exitEdge]
+	if !exists {
+		return 0
+	}
+
+	occupied := 0.0
+	for _, vehicle := range tm.Vehicles {
+		if vehicle.Edge == exitEdge {
+			vt := tm.getVehicleType(vehicle)
+			occupied += vt.Length + vt.MinGap
+		}
+	}
+
+	return length - occupied
+}
+
+func (tm *TrafficManager) recordPriorityGrant(intersectionID, edge string, platoon *models.Platoon,
+	score PriorityScore, forced bool) {
+
+	grant := &PriorityGrant{
+		PlatoonID:      platoon.ID,
+		IntersectionID: intersectionID,
+		Edge:           edge,
+		Policy:         tm.PriorityPolicy.Name,
+		Forced:         forced,
+		Time:           tm.SimulationTime(),
+		Until:          *platoon.PriorityUntil,
+		Score:          score,
+	}
+
+	tm.storePriorityGrant(grant)
+
+	names := make([]string, 0, len(score.Breakdown))
+	for name := range score.Breakdown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	breakdown := ""
+	for _, name := range names {
+		breakdown += fmt.Sprintf(" %s=%.1f(%.1f)", name, score.Breakdown[name], score.Values[name])
+	}
+
+	log.Printf("priority grant for platoon %s at %s under policy %s, score %.1f:%s",
+		platoon.ID, intersectionID, grant.Policy, score.Total, breakdown)
+}
+
+func (tm *TrafficManager) storePriorityGrant(grant *PriorityGrant) {
+	tm.PriorityGrants = append(tm.PriorityGrants, grant)
+	if len(tm.PriorityGrants) > tm.MaxPriorityGrants {
+		tm.PriorityGrants = tm.PriorityGrants[len(tm.PriorityGrants)-tm.MaxPriorityGrants:]
+	}
+}
+
+func (tm *TrafficManager) GetPriorityGrants() []*PriorityGrant {
+	return tm.PriorityGrants
+}
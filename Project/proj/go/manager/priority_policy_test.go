@@ -0,0 +1,35 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePriorityPolicyKeepsUserFeatures(t *testing.T) {
+	policy, err := ParsePriorityPolicy([]byte(`{"name":"queue_only","features":[{"name":"queue","weight":5}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []PriorityFeature{{Name: FeatureQueue, Weight: 5}}
+	if !reflect.DeepEqual(policy.Features, want) {
+		t.Errorf("features = %+v, want %+v", policy.Features, want)
+	}
+	if policy.PriorityWindow != DefaultPriorityPolicy().PriorityWindow {
+		t.Errorf("priority window = %.1f, want the default", policy.PriorityWindow)
+	}
+}
+
+func TestParsePriorityPolicyDefaultsMissingFeatures(t *testing.T) {
+	policy, err := ParsePriorityPolicy([]byte(`{"name":"short_window","priority_window":5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(policy.Features, DefaultPriorityPolicy().Features) {
+		t.Errorf("features = %+v, want the defaults", policy.Features)
+	}
+	if policy.PriorityWindow != 5 {
+		t.Errorf("priority window = %.1f, want 5", policy.PriorityWindow)
+	}
+}
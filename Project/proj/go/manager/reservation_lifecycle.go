@@ -0,0 +1,220 @@
+package manager
+
+import (
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"sumo/models"
+)
+
+type ReservationManager struct {
+	DriftTolerance float64
+	CancelDrift    float64
+	PenaltyWindow  float64
+
+	Shifted   int
+	Cancelled int
+	NoShows   int
+	Blocked   int
+
+	Records map[string]*NoShowRecord
+
+	states map[string]*reservationState
+}
+
+type NoShowRecord struct {
+	NoShows      int       `json:"no_shows"`
+	BlockedUntil time.Time `json:"blocked_until"`
+
+	refused map[string]bool
+}
+
+type reservationState struct {
+	leaderID string
+	arrived  bool
+	shifts   int
+}
+
+func NewReservationManager() *ReservationManager {
+	return &ReservationManager{
+		DriftTolerance: 2.0,
+		CancelDrift:    10.0,
+		PenaltyWindow:  15.0,
+		Records:        make(map[string]*NoShowRecord),
+		states:         make(map[string]*reservationState),
+	}
+}
+
+func (tm *TrafficManager) RenegotiateReservations() {
+	rm := tm.Reservations
+	tm.pruneNoShowRecords()
+
+	for id := range rm.states {
+		if _, exists := tm.IntersectionReservations[id]; !exists {
+			delete(rm.states, id)
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(tm.IntersectionReservations)) {
+		reservation := tm.IntersectionReservations[id]
+
+		state, tracked := rm.states[id]
+		if !tracked {
+			continue
+		}
+		if state.arrived {
+			continue
+		}
+
+		platoon, exists := tm.Platoons[reservation.PlatoonID]
+		if !exists {
+			tm.cancelReservation(id, "platoon dissolved")
+			continue
+		}
+
+		head, crossing := tm.findReservationHead(platoon, reservation)
+		if crossing {
+			state.arrived = true
+			continue
+		}
+		if head == nil {
+			continue
+		}
+
+		eta := tm.estimateArrivalTime(head, tm.estimateDistanceToIntersection(head, nil))
+		drift := eta.Sub(reservation.StartTime)
+		if math.Abs(drift.Seconds()) <= rm.DriftTolerance {
+			continue
+		}
+
+		if drift.Seconds() > rm.CancelDrift {
+			tm.cancelReservation(id, "arrival delayed by "+drift.Round(100*time.Millisecond).String())
+			tm.recordNoShow(id, reservation.PlatoonID, state.leaderID)
+			continue
+		}
+		if drift.Seconds() < -rm.CancelDrift {
+			tm.cancelReservation(id, "arriving "+(-drift).Round(100*time.Millisecond).String()+" early, re-booking")
+			continue
+		}
+
+		shifted := *reservation
+		shifted.StartTime = reservation.StartTime.Add(drift)
+		shifted.EndTime = reservation.EndTime.Add(drift)
+
+		if tm.hasConflictingReservation(&shifted) {
+			tm.cancelReservation(id, "shifted slot conflicts")
+			continue
+		}
+
+		reservation.StartTime = shifted.StartTime
+		reservation.EndTime = shifted.EndTime
+		state.shifts++
+		rm.Shifted++
+
+		log.Printf("reservation %s: shifted by %v for platoon %s (%d shifts)",
+			id, drift.Round(100*time.Millisecond), reservation.PlatoonID, state.shifts)
+	}
+}
+
+func (tm *TrafficManager) findReservationHead(platoon *models.Platoon, reservation *models.IntersectionReservation) (*models.Vehicle, bool) {
+	junctions := tm.getEdgeJunctions()
+
+	var head *models.Vehicle
+	for _, vehicle := range tm.getOrderedPlatoonVehicles(platoon) {
+		if reservation.Direction != "" && tm.getVehicleDirection(vehicle) != reservation.Direction {
+			continue
+		}
+
+		if _, isApproach := junctions[vehicle.Edge]; !isApproach {
+			return nil, true
+		}
+
+		if head == nil {
+			head = vehicle
+		}
+	}
+
+	return head, false
+}
+
+func (tm *TrafficManager) trackReservation(reservation *models.IntersectionReservation, leaderID string) {
+	tm.Reservations.states[reservation.ID] = &reservationState{leaderID: leaderID}
+}
+
+func (tm *TrafficManager) cancelReservation(id, reason string) {
+	rm := tm.Reservations
+	reservation := tm.IntersectionReservations[id]
+
+	delete(tm.IntersectionReservations, id)
+	delete(rm.states, id)
+	rm.Cancelled++
+
+	log.Printf("reservation %s: cancelled for platoon %s, %s", id, reservation.PlatoonID, reason)
+}
+
+func (tm *TrafficManager) expireReservation(id string) {
+	rm := tm.Reservations
+	reservation := tm.IntersectionReservations[id]
+	state, tracked := rm.states[id]
+
+	delete(tm.IntersectionReservations, id)
+	delete(rm.states, id)
+
+	if !tracked || state.arrived {
+		return
+	}
+
+	tm.recordNoShow(id, reservation.PlatoonID, state.leaderID)
+}
+
+func (tm *TrafficManager) recordNoShow(id, platoonID, leaderID string) {
+	rm := tm.Reservations
+	rm.NoShows++
+
+	record, exists := rm.Records[leaderID]
+	if !exists {
+		record = &NoShowRecord{}
+		rm.Records[leaderID] = record
+	}
+	record.NoShows++
+
+	if record.NoShows < 2 {
+		log.Printf("reservation %s: no-show by platoon %s led by %s", id, platoonID, leaderID)
+		return
+	}
+
+	penalty := rm.PenaltyWindow * float64(record.NoShows-1)
+	record.BlockedUntil = tm.SimulationClock().Add(time.Duration(penalty * float64(time.Second)))
+	record.refused = make(map[string]bool)
+
+	log.Printf("reservation %s: no-show %d by %s, blocking its reservations for %.0fs",
+		id, record.NoShows, leaderID, penalty)
+}
+
+func (tm *TrafficManager) isReservationBlocked(leaderID, reservationID string) bool {
+	rm := tm.Reservations
+
+	record, exists := rm.Records[leaderID]
+	if !exists || !tm.SimulationClock().Before(record.BlockedUntil) {
+		return false
+	}
+
+	if !record.refused[reservationID] {
+		record.refused[reservationID] = true
+		rm.Blocked++
+		log.Printf("reservation %s: refused, %s is blocked after %d no-shows", reservationID, leaderID, record.NoShows)
+	}
+	return true
+}
+
+func (tm *TrafficManager) pruneNoShowRecords() {
+	rm := tm.Reservations
+	for id := range rm.Records {
+		if _, exists := tm.Vehicles[id]; !exists {
+			delete(rm.Records, id)
+		}
+	}
+}
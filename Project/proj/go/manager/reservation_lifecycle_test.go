@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"sumo/models"
+)
+
+func renegotiateDriftedReservation(offset float64) *TrafficManager {
+	tm := NewTrafficManager()
+	tm.Vehicles["v0"] = &models.Vehicle{ID: "v0", Edge: "down_incoming", Pos: 26.23, Speed: 10, PlatoonID: "p0", IsLeader: true}
+	tm.Platoons["p0"] = &models.Platoon{ID: "p0", VehicleIDs: []string{"v0"}, LeaderID: "v0", Edge: "down_incoming"}
+
+	start := tm.SimulationClock().Add(time.Duration(offset * float64(time.Second)))
+	reservation := &models.IntersectionReservation{
+		ID:             "p0_:C2",
+		IntersectionID: ":C2",
+		PlatoonID:      "p0",
+		StartTime:      start,
+		EndTime:        start.Add(3 * time.Second),
+		EdgeFrom:       "down_incoming",
+	}
+	tm.IntersectionReservations[reservation.ID] = reservation
+	tm.trackReservation(reservation, "v0")
+
+	tm.RenegotiateReservations()
+	return tm
+}
+
+func TestLateDriftIsRecordedAsNoShow(t *testing.T) {
+	tm := renegotiateDriftedReservation(-15)
+
+	if _, exists := tm.IntersectionReservations["p0_:C2"]; exists {
+		t.Fatal("late reservation was not cancelled")
+	}
+	if tm.Reservations.NoShows != 1 {
+		t.Errorf("no-shows = %d, want 1", tm.Reservations.NoShows)
+	}
+}
+
+func TestEarlyDriftIsNotRecordedAsNoShow(t *testing.T) {
+	tm := renegotiateDriftedReservation(40)
+
+	if _, exists := tm.IntersectionReservations["p0_:C2"]; exists {
+		t.Fatal("early reservation was not cancelled for re-booking")
+	}
+	if tm.Reservations.NoShows != 0 {
+		t.Errorf("no-shows = %d, want 0", tm.Reservations.NoShows)
+	}
+	if len(tm.Reservations.Records) != 0 {
+		t.Errorf("early arrival left a no-show record: %+v", tm.Reservations.Records)
+	}
+}
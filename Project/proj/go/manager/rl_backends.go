@@ -0,0 +1,186 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+
+	"sumo/models"
+)
+
+type EnvironmentVehicle struct {
+	Movement   models.Movement
+	OnApproach bool
+	Speed      float64
+	SpeedLimit float64
+}
+
+type EnvironmentBackend interface {
+	Reset(scenario *EnvironmentScenario, seed int64) (*TrafficManager, error)
+	Step() error
+	Done() bool
+	Time() float64
+	Entered() int
+	Exited() int
+	Vehicles() []EnvironmentVehicle
+	Close()
+}
+
+type SurrogateBackend struct {
+	Manager   *TrafficManager
+	Simulator *JunctionSimulator
+}
+
+func NewSurrogateBackend() *SurrogateBackend {
+	return &SurrogateBackend{}
+}
+
+func (b *SurrogateBackend) Reset(scenario *EnvironmentScenario, seed int64) (*TrafficManager, error) {
+	if scenario.Arrivals == nil {
+		return nil, fmt.Errorf("scenario %q has no arrivals for the junction simulator", scenario.Name)
+	}
+
+	b.Close()
+
+	tm := NewTrafficManager()
+	tm.SetAlgorithm(AlgorithmExternal)
+
+	b.Manager = tm
+	b.Simulator = NewJunctionSimulator(tm, scenario.Arrivals(seed))
+
+	log.Printf("environment: junction simulator loaded %d arrivals", len(b.Simulator.Arrivals))
+	return tm, nil
+}
+
+func (b *SurrogateBackend) Step() error {
+	b.Simulator.Step()
+	return nil
+}
+
+func (b *SurrogateBackend) Done() bool {
+	return b.Simulator.Done()
+}
+
+func (b *SurrogateBackend) Time() float64 {
+	return b.Simulator.Time
+}
+
+func (b *SurrogateBackend) Entered() int {
+	return b.Simulator.Entered
+}
+
+func (b *SurrogateBackend) Exited() int {
+	return b.Simulator.Exited
+}
+
+func (b *SurrogateBackend) Vehicles() []EnvironmentVehicle {
+	vehicles := make([]EnvironmentVehicle, 0, len(b.Simulator.Vehicles))
+	for _, vehicle := range b.Simulator.Vehicles {
+		vehicles = append(vehicles, EnvironmentVehicle{
+			Movement:   vehicle.Movement(),
+			OnApproach: vehicle.OnApproach(),
+			Speed:      vehicle.Speed,
+			SpeedLimit: b.Simulator.EdgeSpeed,
+		})
+	}
+	return vehicles
+}
+
+func (b *SurrogateBackend) Close() {
+	if b.Manager != nil {
+		b.Manager.Agents.Stop()
+	}
+}
+
+type SumoBackend struct {
+	Manager   *TrafficManager
+	StepSumo  func() error
+	EdgeSpeed float64
+
+	seen    map[string]bool
+	entered int
+	exited  int
+	closed  bool
+}
+
+func NewSumoBackend(tm *TrafficManager, stepSumo func() error) *SumoBackend {
+	return &SumoBackend{
+		Manager:   tm,
+		StepSumo:  stepSumo,
+		EdgeSpeed: 13.89,
+		seen:      make(map[string]bool),
+	}
+}
+
+func (b *SumoBackend) Reset(scenario *EnvironmentScenario, seed int64) (*TrafficManager, error) {
+	if b.closed {
+		return nil, fmt.Errorf("sumo connection is closed")
+	}
+
+	b.seen = make(map[string]bool)
+	for id := range b.Manager.Vehicles {
+		b.seen[id] = true
+	}
+	b.entered = 0
+	b.exited = 0
+
+	log.Printf("environment: continuing sumo run at %.1fs, seed %d is ignored", b.Manager.SimulationTime(), seed)
+	return b.Manager, nil
+}
+
+func (b *SumoBackend) Step() error {
+	if err := b.StepSumo(); err != nil {
+		b.closed = true
+		return fmt.Errorf("sumo step failed: %w", err)
+	}
+
+	for id := range b.Manager.Vehicles {
+		if !b.seen[id] {
+			b.seen[id] = true
+			b.entered++
+		}
+	}
+	for id := range b.seen {
+		if _, exists := b.Manager.Vehicles[id]; !exists {
+			delete(b.seen, id)
+			b.exited++
+		}
+	}
+
+	return nil
+}
+
+func (b *SumoBackend) Done() bool {
+	return b.closed
+}
+
+func (b *SumoBackend) Time() float64 {
+	return b.Manager.SimulationTime()
+}
+
+func (b *SumoBackend) Entered() int {
+	return b.entered
+}
+
+func (b *SumoBackend) Exited() int {
+	return b.exited
+}
+
+func (b *SumoBackend) Vehicles() []EnvironmentVehicle {
+	tm := b.Manager
+	junctions := tm.getEdgeJunctions()
+	vehicles := make([]EnvironmentVehicle, 0, len(tm.Vehicles))
+
+	for _, vehicle := range tm.Vehicles {
+		_, onApproach := junctions[vehicle.Edge]
+		vehicles = append(vehicles, EnvironmentVehicle{
+			Movement:   models.Movement{Edge: vehicle.Edge, Direction: tm.getVehicleDirection(vehicle)},
+			OnApproach: onApproach,
+			Speed:      vehicle.Speed,
+			SpeedLimit: b.EdgeSpeed,
+		})
+	}
+
+	return vehicles
+}
+
+func (b *SumoBackend) Close() {}
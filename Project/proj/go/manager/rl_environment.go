@@ -0,0 +1,443 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+
+	"sumo/models"
+)
+
+const (
+	ObservationQueue      = "queue"
+	ObservationDelay      = "delay"
+	ObservationThroughput = "throughput"
+	ObservationPhase      = "phase"
+
+	RewardQueue      = "queue"
+	RewardDelay      = "delay"
+	RewardThroughput = "throughput"
+)
+
+type ObservationBuilder func(env *Environment) []float64
+
+type RewardBuilder func(env *Environment) float64
+
+type RewardTerm struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+type EnvironmentScenario struct {
+	Name     string
+	MaxSteps int
+	Arrivals func(seed int64) []SimulatedArrival
+}
+
+type EnvironmentRequest struct {
+	Command     string         `json:"command"`
+	Scenario    string         `json:"scenario,omitempty"`
+	Seed        int64          `json:"seed,omitempty"`
+	Observation []string       `json:"observation,omitempty"`
+	Reward      []RewardTerm   `json:"reward,omitempty"`
+	Action      map[string]int `json:"action,omitempty"`
+}
+
+type EnvironmentResult struct {
+	Observation []float64              `json:"observation"`
+	Reward      float64                `json:"reward"`
+	Done        bool                   `json:"done"`
+	Info        map[string]interface{} `json:"info"`
+}
+
+type Environment struct {
+	Scenarios    map[string]*EnvironmentScenario
+	Observations map[string]ObservationBuilder
+	Rewards      map[string]RewardBuilder
+	Observation  []string
+	Reward       []RewardTerm
+	ActionRepeat int
+
+	DefaultScenario string
+	Backend         EnvironmentBackend
+
+	Manager  *TrafficManager
+	Scenario *EnvironmentScenario
+	Seed     int64
+	Steps    int
+	Return   float64
+
+	stepExited   int
+	actionExited int
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{
+		Scenarios:    DefaultEnvironmentScenarios(),
+		Observations: DefaultObservationBuilders(),
+		Rewards:      DefaultRewardBuilders(),
+		Observation:  []string{ObservationQueue, ObservationDelay, ObservationPhase},
+		Reward:       []RewardTerm{{Name: RewardDelay, Weight: 1.0}},
+		ActionRepeat: 2,
+
+		DefaultScenario: "uniform",
+		Backend:         NewSurrogateBackend(),
+	}
+}
+
+func NewSumoEnvironment(tm *TrafficManager, stepSumo func() error) *Environment {
+	env := NewEnvironment()
+	env.Scenarios = map[string]*EnvironmentScenario{
+		"sumo": {Name: "sumo", MaxSteps: 700},
+	}
+	env.DefaultScenario = "sumo"
+	env.Backend = NewSumoBackend(tm, stepSumo)
+	return env
+}
+
+func DefaultEnvironmentScenarios() map[string]*EnvironmentScenario {
+	return map[string]*EnvironmentScenario{
+		"adversarial": {
+			Name:     "adversarial",
+			MaxSteps: 700,
+			Arrivals: func(seed int64) []SimulatedArrival {
+				return AdversarialArrivals(seed, 600)
+			},
+		},
+		"uniform": {
+			Name:     "uniform",
+			MaxSteps: 700,
+			Arrivals: func(seed int64) []SimulatedArrival {
+				return UniformArrivals(seed, 600, 0.08)
+			},
+		},
+	}
+}
+
+func UniformArrivals(seed int64, duration, rate float64) []SimulatedArrival {
+	rng := rand.New(rand.NewSource(seed))
+	directions := []string{models.TurnStraight, models.TurnLeft, models.TurnRight}
+	arrivals := make([]SimulatedArrival, 0)
+
+	for _, edge := range []string{"down_incoming", "left_incoming", "right_incoming", "up_incoming"} {
+		index := 0
+		for t := rng.ExpFloat64() / rate; t < duration; t += rng.ExpFloat64() / rate {
+			arrivals = append(arrivals, SimulatedArrival{
+				ID:        fmt.Sprintf("%s_%d", edge, index),
+				Time:      math.Floor(t),
+				EdgeFrom:  edge,
+				Direction: directions[rng.Intn(len(directions))],
+				Type:      "car",
+				Speed:     13.0,
+			})
+			index++
+		}
+	}
+
+	return arrivals
+}
+
+func AdversarialArrivals(seed int64, duration float64) []SimulatedArrival {
+	rng := rand.New(rand.NewSource(seed))
+	directions := []string{models.TurnStraight, models.TurnLeft, models.TurnRight}
+	competitors := []string{"down_incoming", "right_incoming", "up_incoming"}
+	arrivals := make([]SimulatedArrival, 0)
+
+	platoon := 0
+	for start := 0.0; start < duration; start += 12 * (0.75 + rng.Float64()/2) {
+		edge := competitors[platoon%len(competitors)]
+		direction := directions[rng.Intn(len(directions))]
+
+		for i := 0; i < 6; i++ {
+			arrivals = append(arrivals, SimulatedArrival{
+				ID:        fmt.Sprintf("adv_%d_%d", platoon, i),
+				Time:      start + float64(i),
+				EdgeFrom:  edge,
+				Direction: direction,
+				Type:      "car",
+				Speed:     13.0,
+			})
+		}
+		platoon++
+	}
+
+	victim := 0
+	for start := 22.5; start < duration; start += 45 {
+		arrivals = append(arrivals, SimulatedArrival{
+			ID:        fmt.Sprintf("victim_%d", victim),
+			Time:      start + rng.Float64()*45/4,
+			EdgeFrom:  "left_incoming",
+			Direction: models.TurnLeft,
+			Type:      "car",
+			Speed:     10.0,
+		})
+		victim++
+	}
+
+	return arrivals
+}
+
+func DefaultObservationBuilders() map[string]ObservationBuilder {
+	return map[string]ObservationBuilder{
+		ObservationQueue: func(env *Environment) []float64 {
+			return env.perMovement(env.halting)
+		},
+		ObservationDelay: func(env *Environment) []float64 {
+			return env.perMovement(env.delayRate)
+		},
+		ObservationThroughput: func(env *Environment) []float64 {
+			return []float64{float64(env.actionExited)}
+		},
+		ObservationPhase: func(env *Environment) []float64 {
+			tm := env.Manager
+			observation := make([]float64, 0)
+
+			for _, junctionID := range tm.getJunctionIDs() {
+				phases := tm.GetExternalPhases(junctionID)
+				encoded := make([]float64, len(phases)+1)
+
+				if intersection, exists := tm.Intersections[junctionID]; exists && intersection.CurrentControlState != nil {
+					state := intersection.CurrentControlState
+					encoded[state.CurrentPhase] = 1
+					if state.SignalInterval == models.SignalGreen {
+						encoded[len(phases)] = 1
+					}
+				}
+
+				observation = append(observation, encoded...)
+			}
+
+			return observation
+		},
+	}
+}
+
+func DefaultRewardBuilders() map[string]RewardBuilder {
+	return map[string]RewardBuilder{
+		RewardQueue: func(env *Environment) float64 {
+			return -sumValues(env.perMovement(env.halting))
+		},
+		RewardDelay: func(env *Environment) float64 {
+			return -sumValues(env.perMovement(env.delayRate)) * env.Manager.StepLength
+		},
+		RewardThroughput: func(env *Environment) float64 {
+			return float64(env.stepExited)
+		},
+	}
+}
+
+func sumValues(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+func (env *Environment) halting(vehicle EnvironmentVehicle) float64 {
+	if vehicle.OnApproach && vehicle.Speed < 0.1 {
+		return 1
+	}
+	return 0
+}
+
+func (env *Environment) delayRate(vehicle EnvironmentVehicle) float64 {
+	if !vehicle.OnApproach {
+		return 0
+	}
+	return math.Max(1-vehicle.Speed/vehicle.SpeedLimit, 0)
+}
+
+func (env *Environment) perMovement(value func(vehicle EnvironmentVehicle) float64) []float64 {
+	tm := env.Manager
+	index := make(map[models.Movement]int)
+	values := make([]float64, 0)
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		for _, movement := range tm.getJunctionMovements(junctionID) {
+			index[movement] = len(values)
+			values = append(values, 0)
+		}
+	}
+
+	for _, vehicle := range env.Backend.Vehicles() {
+		if i, exists := index[vehicle.Movement]; exists {
+			values[i] += value(vehicle)
+		}
+	}
+
+	return values
+}
+
+func (env *Environment) Handle(request EnvironmentRequest) (*EnvironmentResult, error) {
+	switch request.Command {
+	case "reset":
+		observation, reward := env.Observation, env.Reward
+		if len(request.Observation) > 0 {
+			env.Observation = request.Observation
+		}
+		if len(request.Reward) > 0 {
+			env.Reward = request.Reward
+		}
+
+		result, err := env.Reset(request.Scenario, request.Seed)
+		if err != nil {
+			env.Observation, env.Reward = observation, reward
+		}
+		return result, err
+	case "step":
+		return env.Step(request.Action)
+	}
+
+	return nil, fmt.Errorf("unknown environment command %q", request.Command)
+}
+
+func (env *Environment) validate() error {
+	for _, name := range env.Observation {
+		if _, exists := env.Observations[name]; !exists {
+			return fmt.Errorf("unknown observation builder %q", name)
+		}
+	}
+	for _, term := range env.Reward {
+		if _, exists := env.Rewards[term.Name]; !exists {
+			return fmt.Errorf("unknown reward builder %q", term.Name)
+		}
+	}
+	return nil
+}
+
+func (env *Environment) Reset(scenarioName string, seed int64) (*EnvironmentResult, error) {
+	if scenarioName == "" {
+		scenarioName = env.DefaultScenario
+	}
+
+	scenario, exists := env.Scenarios[scenarioName]
+	if !exists {
+		return nil, fmt.Errorf("unknown scenario %q", scenarioName)
+	}
+	if err := env.validate(); err != nil {
+		return nil, err
+	}
+
+	tm, err := env.Backend.Reset(scenario, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	env.Manager = tm
+	env.Scenario = scenario
+	env.Seed = seed
+	env.Steps = 0
+	env.Return = 0
+	env.stepExited = 0
+	env.actionExited = 0
+
+	log.Printf("environment: reset scenario %s with seed %d", scenario.Name, seed)
+
+	phases := make(map[string][]string)
+	for _, junctionID := range tm.getJunctionIDs() {
+		for _, phase := range tm.GetExternalPhases(junctionID) {
+			phases[junctionID] = append(phases[junctionID], phase.Name)
+		}
+	}
+
+	info := env.info(nil)
+	info["phases"] = phases
+
+	return &EnvironmentResult{
+		Observation: env.observe(),
+		Info:        info,
+	}, nil
+}
+
+func (env *Environment) Step(action map[string]int) (*EnvironmentResult, error) {
+	if env.Manager == nil {
+		return nil, fmt.Errorf("environment must be reset before stepping")
+	}
+	if env.done() {
+		return nil, fmt.Errorf("episode is done, reset the environment")
+	}
+
+	for junctionID, phase := range action {
+		if err := env.Manager.RequestExternalPhase(junctionID, phase); err != nil {
+			return nil, err
+		}
+	}
+
+	reward := 0.0
+	breakdown := make(map[string]float64)
+	env.actionExited = 0
+
+	for i := 0; i < env.ActionRepeat && !env.done(); i++ {
+		exited := env.Backend.Exited()
+		if err := env.Backend.Step(); err != nil {
+			log.Printf("environment: %v", err)
+			break
+		}
+		env.Steps++
+
+		env.stepExited = env.Backend.Exited() - exited
+		env.actionExited += env.stepExited
+
+		for _, term := range env.Reward {
+			value := term.Weight * env.Rewards[term.Name](env)
+			breakdown[term.Name] += value
+			reward += value
+		}
+	}
+
+	env.Return += reward
+	done := env.done()
+
+	if done {
+		log.Printf("environment: episode %s seed %d done after %d steps, return %.1f, %d/%d vehicles exited",
+			env.Scenario.Name, env.Seed, env.Steps, env.Return, env.Backend.Exited(), env.Backend.Entered())
+	}
+
+	return &EnvironmentResult{
+		Observation: env.observe(),
+		Reward:      reward,
+		Done:        done,
+		Info:        env.info(breakdown),
+	}, nil
+}
+
+func (env *Environment) Close() {
+	env.Backend.Close()
+}
+
+func (env *Environment) done() bool {
+	return env.Backend.Done() || env.Steps >= env.Scenario.MaxSteps
+}
+
+func (env *Environment) observe() []float64 {
+	observation := make([]float64, 0)
+	for _, name := range env.Observation {
+		observation = append(observation, env.Observations[name](env)...)
+	}
+	return observation
+}
+
+func (env *Environment) info(breakdown map[string]float64) map[string]interface{} {
+	tm := env.Manager
+
+	current := make(map[string]int)
+	for _, junctionID := range tm.getJunctionIDs() {
+		if intersection, exists := tm.Intersections[junctionID]; exists && intersection.CurrentControlState != nil {
+			current[junctionID] = intersection.CurrentControlState.CurrentPhase
+		}
+	}
+
+	return map[string]interface{}{
+		"time":     env.Backend.Time(),
+		"steps":    env.Steps,
+		"entered":  env.Backend.Entered(),
+		"exited":   env.Backend.Exited(),
+		"vehicles": len(env.Backend.Vehicles()),
+		"return":   env.Return,
+		"rewards":  breakdown,
+		"phase":    current,
+		"switches": tm.External.Switches,
+	}
+}
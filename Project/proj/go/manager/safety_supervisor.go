@@ -0,0 +1,251 @@
+package manager
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"sumo/models"
+)
+
+type SafetySupervisor struct {
+	Enabled        bool
+	Horizon        float64
+	ApproachRange  float64
+	ZoneRadius     float64
+	TimeMargin     float64
+	StopLineOffset float64
+
+	StepInterventions  int
+	TotalInterventions int
+	Overrides          map[string]float64
+
+	zones map[[2]models.Movement][][2]float64
+}
+
+type zoneOccupant struct {
+	vehicle   *models.Vehicle
+	movement  models.Movement
+	progress  float64
+	committed bool
+	entry     float64
+}
+
+func NewSafetySupervisor() *SafetySupervisor {
+	return &SafetySupervisor{
+		Enabled:        true,
+		Horizon:        10.0,
+		ApproachRange:  80.0,
+		ZoneRadius:     2.0,
+		TimeMargin:     1.0,
+		StopLineOffset: 1.0,
+		Overrides:      make(map[string]float64),
+		zones:          make(map[[2]models.Movement][][2]float64),
+	}
+}
+
+func (tm *TrafficManager) SuperviseCommands() {
+	ss := tm.Safety
+	ss.StepInterventions = 0
+	ss.Overrides = make(map[string]float64)
+
+	if !ss.Enabled {
+		return
+	}
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		occupants := tm.collectZoneOccupants(junctionID)
+		accepted := make([]*zoneOccupant, 0, len(occupants))
+
+		for _, occupant := range occupants {
+			other, start, end := tm.findZoneConflict(occupant, accepted)
+			if other == nil {
+				accepted = append(accepted, occupant)
+				continue
+			}
+
+			if occupant.committed {
+				log.Printf("safety %s: %s (%s %s) cannot stop before conflict with %s at %.1f-%.1fs",
+					junctionID, occupant.vehicle.ID, occupant.movement.Edge, occupant.movement.Direction,
+					other.vehicle.ID, start, end)
+				accepted = append(accepted, occupant)
+				continue
+			}
+
+			tm.applySafeStop(junctionID, occupant, other, start, end)
+		}
+	}
+
+	ss.TotalInterventions += ss.StepInterventions
+}
+
+func (tm *TrafficManager) collectZoneOccupants(junctionID string) []*zoneOccupant {
+	ss := tm.Safety
+	junctions := tm.getEdgeJunctions()
+	occupants := make([]*zoneOccupant, 0)
+
+	for _, vehicle := range tm.Vehicles {
+		occupant := &zoneOccupant{vehicle: vehicle}
+
+		if movement, offset, internal := tm.getInternalEdgeMovement(vehicle.Edge); internal {
+			if junctions[movement.Edge] != junctionID {
+				continue
+			}
+
+			occupant.movement = movement
+			occupant.progress = offset + vehicle.Pos
+			occupant.committed = true
+		} else {
+			if junctions[vehicle.Edge] != junctionID {
+				continue
+			}
+
+			distance := tm.estimateDistanceToIntersection(vehicle, nil)
+			if distance < 0 || distance > ss.ApproachRange {
+				continue
+			}
+
+			vt := tm.getVehicleType(vehicle)
+			occupant.movement = models.Movement{Edge: vehicle.Edge, Direction: tm.getVehicleDirection(vehicle)}
+			occupant.progress = -distance
+			occupant.committed = !vehicle.Connected || vehicle.Speed*vehicle.Speed/(2*vt.MaxDecel) > distance-ss.StopLineOffset
+		}
+
+		if tm.getMovementPath(occupant.movement.Edge, occupant.movement.Direction) == nil {
+			continue
+		}
+
+		occupant.entry = tm.predictTravelTime(vehicle, -occupant.progress)
+		occupants = append(occupants, occupant)
+	}
+
+	sort.Slice(occupants, func(i, j int) bool {
+		if occupants[i].committed != occupants[j].committed {
+			return occupants[i].committed
+		}
+		if emergency := tm.isEmergencyVehicle(occupants[i].vehicle); emergency != tm.isEmergencyVehicle(occupants[j].vehicle) {
+			return emergency
+		}
+		if occupants[i].entry != occupants[j].entry {
+			return occupants[i].entry < occupants[j].entry
+		}
+		return occupants[i].vehicle.ID < occupants[j].vehicle.ID
+	})
+
+	return occupants
+}
+
+func (tm *TrafficManager) getConflictZones(a, b models.Movement) [][2]float64 {
+	ss := tm.Safety
+	key := [2]models.Movement{a, b}
+
+	if zones, exists := ss.zones[key]; exists {
+		return zones
+	}
+
+	zones := make([][2]float64, 0)
+	if a.Edge != b.Edge {
+		zones = pathIntersections(tm.getMovementPath(a.Edge, a.Direction), tm.getMovementPath(b.Edge, b.Direction))
+	}
+
+	ss.zones[key] = zones
+	return zones
+}
+
+func (tm *TrafficManager) findZoneConflict(occupant *zoneOccupant, accepted []*zoneOccupant) (*zoneOccupant, float64, float64) {
+	ss := tm.Safety
+
+	for _, other := range accepted {
+		for _, zone := range tm.getConflictZones(occupant.movement, other.movement) {
+			start, end, occupies := tm.predictZoneOccupancy(occupant, zone[0])
+			if !occupies {
+				continue
+			}
+
+			otherStart, otherEnd, otherOccupies := tm.predictZoneOccupancy(other, zone[1])
+			if !otherOccupies {
+				continue
+			}
+
+			if start < otherEnd+ss.TimeMargin && otherStart < end+ss.TimeMargin {
+				return other, start, end
+			}
+		}
+	}
+
+	return nil, 0, 0
+}
+
+func (tm *TrafficManager) predictZoneOccupancy(occupant *zoneOccupant, zoneOffset float64) (float64, float64, bool) {
+	ss := tm.Safety
+	length := tm.getVehicleType(occupant.vehicle).Length
+
+	exitDistance := zoneOffset + ss.ZoneRadius + length - occupant.progress
+	if exitDistance <= 0 {
+		return 0, 0, false
+	}
+
+	start := tm.predictTravelTime(occupant.vehicle, zoneOffset-ss.ZoneRadius-occupant.progress)
+	if start > ss.Horizon {
+		return 0, 0, false
+	}
+
+	return start, tm.predictTravelTime(occupant.vehicle, exitDistance), true
+}
+
+func (tm *TrafficManager) predictTravelTime(vehicle *models.Vehicle, distance float64) float64 {
+	if distance <= 0 {
+		return 0
+	}
+
+	vt := tm.getVehicleType(vehicle)
+	v0, v1 := vehicle.Speed, math.Max(vehicle.DesiredSpeed, 0)
+
+	accel := vt.MaxAccel
+	if v1 < v0 {
+		accel = -vt.MaxDecel
+	}
+
+	changeTime := 0.0
+	if accel != 0 {
+		changeTime = (v1 - v0) / accel
+	}
+	changeDistance := (v0 + v1) / 2 * changeTime
+
+	if distance <= changeDistance {
+		discriminant := v0*v0 + 2*accel*distance
+		if discriminant < 0 {
+			return math.Inf(1)
+		}
+		return (math.Sqrt(discriminant) - v0) / accel
+	}
+
+	if v1 <= 0 {
+		return math.Inf(1)
+	}
+
+	return changeTime + (distance-changeDistance)/v1
+}
+
+func (tm *TrafficManager) applySafeStop(junctionID string, occupant, other *zoneOccupant, start, end float64) {
+	ss := tm.Safety
+	vehicle := occupant.vehicle
+	vt := tm.getVehicleType(vehicle)
+
+	distance := -occupant.progress - ss.StopLineOffset
+	decel := vt.MaxDecel
+	if distance > 0 {
+		decel = math.Min(math.Max(vehicle.Speed*vehicle.Speed/(2*distance), vt.ComfortDecel), vt.MaxDecel)
+	}
+
+	proposed := vehicle.DesiredSpeed
+	safeSpeed := math.Min(proposed, math.Max(vehicle.Speed-decel*tm.StepLength, 0))
+
+	vehicle.DesiredSpeed = safeSpeed
+	ss.Overrides[vehicle.ID] = safeSpeed
+	ss.StepInterventions++
+
+	log.Printf("safety %s: %s (%s %s) would occupy a conflict zone at %.1f-%.1fs with %s (%s %s), overriding %.1f -> %.1f m/s",
+		junctionID, vehicle.ID, occupant.movement.Edge, occupant.movement.Direction, start, end,
+		other.vehicle.ID, other.movement.Edge, other.movement.Direction, proposed, safeSpeed)
+}
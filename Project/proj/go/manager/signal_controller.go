@@ -0,0 +1,217 @@
+package manager
+
+import (
+	"log"
+	"math"
+
+	"sumo/models"
+)
+
+type SignalController struct {
+	Phases         []models.SignalPhase
+	YellowTime     float64
+	AllRedTime     float64
+	GapTime        float64
+	DetectorLength float64
+	StopLineOffset float64
+	ComfortDecel   float64
+}
+
+func NewSignalController() *SignalController {
+	return &SignalController{
+		Phases:         defaultSignalPhases(),
+		YellowTime:     3.0,
+		AllRedTime:     2.0,
+		GapTime:        3.0,
+		DetectorLength: 30.0,
+		StopLineOffset: 1.0,
+		ComfortDecel:   3.0,
+	}
+}
+
+func defaultSignalPhases() []models.SignalPhase {
+	approachPhase := func(name, edge string) models.SignalPhase {
+		return models.SignalPhase{
+			Name: name,
+			Movements: []models.Movement{
+				{Edge: edge, Direction: models.TurnLeft},
+				{Edge: edge, Direction: models.TurnStraight},
+				{Edge: edge, Direction: models.TurnRight},
+			},
+			Green:    20.0,
+			MinGreen: 6.0,
+			MaxGreen: 40.0,
+		}
+	}
+
+	return []models.SignalPhase{
+		approachPhase("down", "down_incoming"),
+		approachPhase("left", "left_incoming"),
+		approachPhase("up", "up_incoming"),
+		approachPhase("right", "right_incoming"),
+	}
+}
+
+func phaseAllows(phase models.SignalPhase, edge, direction string) bool {
+	for _, movement := range phase.Movements {
+		if movement.Edge == edge && movement.Direction == direction {
+			return true
+		}
+	}
+	return false
+}
+
+func (tm *TrafficManager) ManageSignals(actuated bool) {
+	sc := tm.Signals
+	now := tm.SimulationTime()
+
+	for _, junctionID := range tm.getJunctionIDs() {
+		intersection := tm.getOrCreateIntersection(junctionID)
+
+		if intersection.CurrentControlState == nil {
+			intersection.CurrentControlState = &models.IntersectionControlState{
+				CurrentPhase:      0,
+				SignalInterval:    models.SignalGreen,
+				IntervalStartTime: now,
+				LastDetectionTime: now,
+			}
+		}
+
+		tm.advanceSignal(intersection, actuated, now)
+
+		state := intersection.CurrentControlState
+		tm.applySignalCommands(junctionID, sc.Phases[state.CurrentPhase], state.SignalInterval)
+	}
+}
+
+func (tm *TrafficManager) advanceSignal(intersection *models.Intersection, actuated bool, now float64) {
+	sc := tm.Signals
+	state := intersection.CurrentControlState
+	phase := sc.Phases[state.CurrentPhase]
+	elapsed := now - state.IntervalStartTime
+
+	switch state.SignalInterval {
+	case models.SignalGreen:
+		if !actuated {
+			if elapsed >= phase.Green {
+				tm.setSignalInterval(intersection, models.SignalYellow, now)
+			}
+			return
+		}
+
+		if tm.isPhaseDetectorOccupied(phase) {
+			state.LastDetectionTime = now
+		}
+
+		maxedOut := elapsed >= phase.MaxGreen
+		gappedOut := elapsed >= phase.MinGreen && now-state.LastDetectionTime >= sc.GapTime
+
+		if (maxedOut || gappedOut) && tm.nextSignalPhase(state.CurrentPhase, true) != state.CurrentPhase {
+			tm.setSignalInterval(intersection, models.SignalYellow, now)
+		}
+
+	case models.SignalYellow:
+		if elapsed >= sc.YellowTime {
+			tm.setSignalInterval(intersection, models.SignalAllRed, now)
+		}
+
+	case models.SignalAllRed:
+		if elapsed >= sc.AllRedTime {
+			state.CurrentPhase = tm.nextSignalPhase(state.CurrentPhase, actuated)
+			state.LastDetectionTime = now
+			tm.setSignalInterval(intersection, models.SignalGreen, now)
+		}
+	}
+}
+
+func (tm *TrafficManager) setSignalInterval(intersection *models.Intersection, interval string, now float64) {
+	state := intersection.CurrentControlState
+	state.SignalInterval = interval
+	state.IntervalStartTime = now
+
+	log.Printf("signal %s: phase %d now %s at %.1fs", intersection.ID, state.CurrentPhase, interval, now)
+}
+
+func (tm *TrafficManager) nextSignalPhase(current int, skipWithoutDemand bool) int {
+	phases := tm.Signals.Phases
+
+	for i := 1; i <= len(phases); i++ {
+		candidate := (current + i) % len(phases)
+		if !skipWithoutDemand || tm.hasPhaseDemand(phases[candidate]) {
+			return candidate
+		}
+	}
+
+	return current
+}
+
+func (tm *TrafficManager) hasPhaseDemand(phase models.SignalPhase) bool {
+	for _, vehicle := range tm.Vehicles {
+		if phaseAllows(phase, vehicle.Edge, tm.getVehicleDirection(vehicle)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (tm *TrafficManager) isPhaseDetectorOccupied(phase models.SignalPhase) bool {
+	for _, vehicle := range tm.Vehicles {
+		if !phaseAllows(phase, vehicle.Edge, tm.getVehicleDirection(vehicle)) {
+			continue
+		}
+
+		distance := tm.estimateDistanceToIntersection(vehicle, nil)
+		if distance >= 0 && distance <= tm.Signals.DetectorLength {
+			return true
+		}
+	}
+	return false
+}
+
+func (tm *TrafficManager) getVehicleDirection(vehicle *models.Vehicle) string {
+	if vehicle.TurnDirection == "" {
+		vehicle.TurnDirection = tm.determineTurnDirection(vehicle, vehicle.NextEdge)
+	}
+	return vehicle.TurnDirection
+}
+
+func (tm *TrafficManager) applySignalCommands(junctionID string, phase models.SignalPhase, interval string) {
+	junctions := tm.getEdgeJunctions()
+
+	for _, vehicle := range tm.Vehicles {
+		if junctions[vehicle.Edge] != junctionID {
+			continue
+		}
+
+		distance := tm.estimateDistanceToIntersection(vehicle, nil)
+		if distance < 0 {
+			continue
+		}
+
+		allowed := phaseAllows(phase, vehicle.Edge, tm.getVehicleDirection(vehicle))
+
+		switch {
+		case allowed && interval == models.SignalGreen:
+			continue
+		case allowed && interval == models.SignalYellow && !tm.canStopComfortably(vehicle, distance):
+			continue
+		}
+
+		vehicle.DesiredSpeed = math.Min(vehicle.DesiredSpeed, tm.stopProfileSpeed(distance))
+	}
+}
+
+func (tm *TrafficManager) canStopComfortably(vehicle *models.Vehicle, distance float64) bool {
+	stoppingDistance := vehicle.Speed * vehicle.Speed / (2 * tm.Signals.ComfortDecel)
+	return stoppingDistance <= distance-tm.Signals.StopLineOffset
+}
+
+func (tm *TrafficManager) stopProfileSpeed(distance float64) float64 {
+	return math.Sqrt(2 * tm.Signals.ComfortDecel * math.Max(distance-tm.Signals.StopLineOffset, 0))
+}
+
+func (tm *TrafficManager) applyFreeFlowSpeeds() {
+	for _, vehicle := range tm.Vehicles {
+		vehicle.DesiredSpeed = 13.9
+	}
+}
@@ -0,0 +1,281 @@
+package manager
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"sumo/models"
+)
+
+type StringStabilityAnalyzer struct {
+	Window         int
+	MinSamples     int
+	MinLeaderError float64
+	MinGapError    float64
+	Tolerance      float64
+
+	Metrics map[string]*PlatoonStringStability
+	traces  map[string]*stringStabilityTrace
+}
+
+type PlatoonStringStability struct {
+	PlatoonID          string    `json:"platoon"`
+	Size               int       `json:"size"`
+	Samples            int       `json:"samples"`
+	SpeedErrors        []float64 `json:"speed_errors"`
+	GapErrors          []float64 `json:"gap_errors"`
+	SpeedAmplification float64   `json:"speed_amplification"`
+	GapAmplification   float64   `json:"gap_amplification"`
+	LeaderExcited      bool      `json:"leader_excited"`
+	StringStable       bool      `json:"string_stable"`
+}
+
+type stringStabilityTrace struct {
+	members   string
+	speeds    [][]float64
+	gapErrors [][]float64
+}
+
+type StringStabilityScenario struct {
+	Size         int
+	VehicleType  string
+	InitialSpeed float64
+	DisturbedAt  float64
+	DisturbedFor float64
+	DisturbedTo  float64
+	Steps        int
+	Substeps     int
+}
+
+func NewStringStabilityAnalyzer() *StringStabilityAnalyzer {
+	return &StringStabilityAnalyzer{
+		Window:         30,
+		MinSamples:     10,
+		MinLeaderError: 0.3,
+		MinGapError:    0.2,
+		Tolerance:      0.05,
+		Metrics:        make(map[string]*PlatoonStringStability),
+		traces:         make(map[string]*stringStabilityTrace),
+	}
+}
+
+func (tm *TrafficManager) RecordStringStability() {
+	sa := tm.StringStability
+
+	for platoonID := range sa.traces {
+		if _, exists := tm.Platoons[platoonID]; !exists {
+			delete(sa.traces, platoonID)
+			delete(sa.Metrics, platoonID)
+		}
+	}
+
+	for platoonID, platoon := range tm.Platoons {
+		ordered := tm.getOrderedPlatoonVehicles(platoon)
+		if len(ordered) < 2 {
+			continue
+		}
+
+		members := strings.Join(tm.vehicleIDs(ordered), ",")
+		trace, exists := sa.traces[platoonID]
+		if !exists || trace.members != members {
+			trace = &stringStabilityTrace{members: members}
+			sa.traces[platoonID] = trace
+		}
+
+		if !tm.recordStringStabilitySample(trace, ordered) {
+			continue
+		}
+
+		sa.Metrics[platoonID] = sa.evaluate(platoonID, trace)
+	}
+}
+
+func (tm *TrafficManager) recordStringStabilitySample(trace *stringStabilityTrace, ordered []*models.Vehicle) bool {
+	sa := tm.StringStability
+
+	speeds := make([]float64, len(ordered))
+	gapErrors := make([]float64, len(ordered)-1)
+
+	for i, vehicle := range ordered {
+		speeds[i] = vehicle.Speed
+		if i == 0 {
+			continue
+		}
+
+		front := ordered[i-1]
+		if front.Edge != vehicle.Edge || front.Lane != vehicle.Lane {
+			return false
+		}
+
+		gapErrors[i-1] = tm.calculateBumperGap(vehicle, front) - tm.calculateOptimalGap(vehicle, front)
+	}
+
+	trace.speeds = append(trace.speeds, speeds)
+	trace.gapErrors = append(trace.gapErrors, gapErrors)
+
+	if len(trace.speeds) > sa.Window {
+		trace.speeds = trace.speeds[len(trace.speeds)-sa.Window:]
+		trace.gapErrors = trace.gapErrors[len(trace.gapErrors)-sa.Window:]
+	}
+
+	return true
+}
+
+func (sa *StringStabilityAnalyzer) evaluate(platoonID string, trace *stringStabilityTrace) *PlatoonStringStability {
+	size := len(trace.speeds[0])
+	result := &PlatoonStringStability{
+		PlatoonID:    platoonID,
+		Size:         size,
+		Samples:      len(trace.speeds),
+		SpeedErrors:  make([]float64, size),
+		GapErrors:    make([]float64, size-1),
+		StringStable: true,
+	}
+
+	if result.Samples < sa.MinSamples {
+		return result
+	}
+
+	for i := 0; i < size; i++ {
+		mean := 0.0
+		for _, sample := range trace.speeds {
+			mean += sample[i]
+		}
+		mean /= float64(result.Samples)
+
+		for _, sample := range trace.speeds {
+			result.SpeedErrors[i] += (sample[i] - mean) * (sample[i] - mean)
+		}
+		result.SpeedErrors[i] = math.Sqrt(result.SpeedErrors[i] / float64(result.Samples))
+	}
+
+	for i := 0; i < size-1; i++ {
+		for _, sample := range trace.gapErrors {
+			result.GapErrors[i] += sample[i] * sample[i]
+		}
+		result.GapErrors[i] = math.Sqrt(result.GapErrors[i] / float64(result.Samples))
+	}
+
+	leaderError := result.SpeedErrors[0]
+	result.LeaderExcited = leaderError >= sa.MinLeaderError
+	if !result.LeaderExcited {
+		return result
+	}
+
+	result.SpeedAmplification = result.SpeedErrors[size-1] / leaderError
+	if result.GapErrors[0] >= sa.MinGapError {
+		result.GapAmplification = result.GapErrors[size-2] / result.GapErrors[0]
+	}
+
+	result.StringStable = result.SpeedAmplification <= 1+sa.Tolerance &&
+		result.GapAmplification <= 1+sa.Tolerance
+
+	return result
+}
+
+func (tm *TrafficManager) GetStringStabilityMetrics() map[string]*PlatoonStringStability {
+	return tm.StringStability.Metrics
+}
+
+func (tm *TrafficManager) calculateStringStabilityStats() (float64, float64, int) {
+	maxSpeed, maxGap := 0.0, 0.0
+	unstable := 0
+
+	for _, metrics := range tm.StringStability.Metrics {
+		maxSpeed = math.Max(maxSpeed, metrics.SpeedAmplification)
+		maxGap = math.Max(maxGap, metrics.GapAmplification)
+		if !metrics.StringStable {
+			unstable++
+		}
+	}
+
+	return maxSpeed, maxGap, unstable
+}
+
+func DefaultStringStabilityScenario() StringStabilityScenario {
+	return StringStabilityScenario{
+		Size:         6,
+		VehicleType:  "car",
+		InitialSpeed: 15.0,
+		DisturbedAt:  5.0,
+		DisturbedFor: 4.0,
+		DisturbedTo:  10.0,
+		Steps:        60,
+		Substeps:     10,
+	}
+}
+
+func RunStringStabilityScenario(scenario StringStabilityScenario) *PlatoonStringStability {
+	tm := NewTrafficManager()
+	tm.StringStability.Window = scenario.Steps
+	tm.StringStability.MinSamples = scenario.Steps / 2
+
+	platoon := &models.Platoon{
+		ID:   "string_stability",
+		Edge: "string_stability",
+		Lane: "string_stability_0",
+	}
+
+	pos := 0.0
+	for i := 0; i < scenario.Size; i++ {
+		vehicle := &models.Vehicle{
+			ID:        fmt.Sprintf("ss_%d", i),
+			Edge:      platoon.Edge,
+			Lane:      platoon.Lane,
+			Speed:     scenario.InitialSpeed,
+			Type:      scenario.VehicleType,
+			PlatoonID: platoon.ID,
+			IsLeader:  i == 0,
+		}
+
+		if i > 0 {
+			front := tm.Vehicles[platoon.VehicleIDs[i-1]]
+			pos -= tm.getVehicleType(front).Length + tm.calculateOptimalGap(vehicle, front)
+		}
+		vehicle.Pos = pos
+
+		tm.Vehicles[vehicle.ID] = vehicle
+		tm.VehicleToPlatoon[vehicle.ID] = platoon.ID
+		platoon.VehicleIDs = append(platoon.VehicleIDs, vehicle.ID)
+	}
+
+	platoon.LeaderID = platoon.VehicleIDs[0]
+	tm.Platoons[platoon.ID] = platoon
+	leader := tm.Vehicles[platoon.LeaderID]
+
+	for step := 0; step < scenario.Steps; step++ {
+		now := float64(step) * tm.StepLength
+		tm.TimeStep = step
+
+		leaderTarget := scenario.InitialSpeed
+		if now >= scenario.DisturbedAt && now < scenario.DisturbedAt+scenario.DisturbedFor {
+			leaderTarget = scenario.DisturbedTo
+		}
+
+		accel := tm.idmAcceleration(leader, nil, leaderTarget, 0)
+		if leaderTarget < leader.Speed {
+			accel = (leaderTarget - leader.Speed) / tm.StepLength
+		}
+		leader.DesiredSpeed = tm.commandSpeed(leader, accel, math.Max(leaderTarget, leader.Speed))
+
+		tm.processPlatoonsIndependently()
+
+		dt := tm.StepLength / float64(scenario.Substeps)
+		for i := 0; i < scenario.Substeps; i++ {
+			for _, vehicle := range tm.Vehicles {
+				previous := vehicle.Speed
+				vehicle.Speed += (vehicle.DesiredSpeed - previous) / float64(scenario.Substeps-i)
+				vehicle.Pos += (previous + vehicle.Speed) / 2 * dt
+			}
+		}
+
+		for _, vehicle := range tm.Vehicles {
+			vehicle.Acceleration = vehicle.CommandedAccel
+		}
+
+		tm.RecordStringStability()
+	}
+
+	return tm.StringStability.Metrics[platoon.ID]
+}
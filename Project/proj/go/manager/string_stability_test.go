@@ -0,0 +1,34 @@
+package manager
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestDefaultScenarioIsStringStable(t *testing.T) {
+	metrics := RunStringStabilityScenario(DefaultStringStabilityScenario())
+	if metrics == nil {
+		t.Fatal("no string stability metrics recorded")
+	}
+
+	if !metrics.LeaderExcited {
+		t.Fatalf("leader disturbance not detected: %+v", metrics)
+	}
+
+	if !metrics.StringStable {
+		t.Errorf("platoon is not string stable: %+v", metrics)
+	}
+	if metrics.SpeedAmplification > 1 {
+		t.Errorf("speed error amplified by %.3f", metrics.SpeedAmplification)
+	}
+	if metrics.GapAmplification > 1 {
+		t.Errorf("gap error amplified by %.3f", metrics.GapAmplification)
+	}
+}
@@ -29,6 +29,8 @@ type TrafficManager struct {
 	IntersectionReservations map[string]*models.IntersectionReservation
 	TrafficDensity           map[string]float64
 	LastTrafficMeasurement   time.Time
+	EdgeVehicleCounts        map[string]int
+	MovementVehicleCounts    map[models.Movement]int
 
 	BenchmarkMode        bool
 	BenchmarkName        string
@@ -41,8 +43,56 @@ type TrafficManager struct {
 	TotalRemovedVehicles int
 	StopBenchmark        bool
 	UseCustomAlgorithm   bool
+	Algorithm            string
+
+	StepLength   float64
+	VehicleTypes map[string]*models.VehicleType
+	AIM          *AIMController
+	Signals      *SignalController
+	MaxPressure  *MaxPressureController
+	Arrivals     *ArrivalScheduler
+	Formation    *FormationPlanner
+	Maneuvers    *ManeuverController
+	Shaper       *CommandShaper
+	Safety       *SafetySupervisor
+	Preemption   *PreemptionController
+	Fairness     *FairnessGuard
+
+	CrossingOrder *CrossingOrderScheduler
+	External      *ExternalPhaseController
+	Prediction    *ArrivalPredictor
+	Emissions     *EmissionModel
+	EcoDriving    *EcoDriver
+	Connectivity  *ConnectivityModel
+	V2X           *V2XChannel
+	Agents        *AgentCoordinator
+	SizePolicy    *PlatoonSizePolicy
+	Divergence    *DivergencePlanner
+	Dilemma       *DilemmaZoneGuard
+	Reservations  *ReservationManager
+
+	StringStability *StringStabilityAnalyzer
+	VehicleClasses  map[string]*models.VehicleClass
+
+	PriorityPolicy    *PriorityPolicy
+	PriorityGrants    []*PriorityGrant
+	MaxPriorityGrants int
 }
 
+const (
+	AlgorithmCustom = "custom"
+	AlgorithmSumo   = "sumo"
+	AlgorithmAIM    = "aim"
+
+	AlgorithmFixedTime   = "fixed"
+	AlgorithmActuated    = "actuated"
+	AlgorithmMaxPressure = "maxpressure"
+	AlgorithmOptimal     = "optimal"
+	AlgorithmExternal    = "external"
+)
+
+var SimulationEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func NewTrafficManager() *TrafficManager {
 	return &TrafficManager{
 		Vehicles:          make(map[string]*models.Vehicle),
@@ -61,14 +111,78 @@ func NewTrafficManager() *TrafficManager {
 		StablePlatoonSpeed:       22.2,
 		IntersectionReservations: make(map[string]*models.IntersectionReservation),
 		TrafficDensity:           make(map[string]float64),
-		LastTrafficMeasurement:   time.Now(),
+		LastTrafficMeasurement:   SimulationEpoch,
+		EdgeVehicleCounts:        make(map[string]int),
+		MovementVehicleCounts:    make(map[models.Movement]int),
 
 		UseCustomAlgorithm: true,
+		Algorithm:          AlgorithmCustom,
+
+		StepLength:   1.0,
+		VehicleTypes: DefaultVehicleTypes(),
+		AIM:          NewAIMController(),
+		Signals:      NewSignalController(),
+		MaxPressure:  NewMaxPressureController(),
+		Arrivals:     NewArrivalScheduler(),
+		Formation:    NewFormationPlanner(),
+		Maneuvers:    NewManeuverController(),
+		Shaper:       NewCommandShaper(),
+		Safety:       NewSafetySupervisor(),
+		Preemption:   NewPreemptionController(),
+		Fairness:     NewFairnessGuard(),
+
+		CrossingOrder: NewCrossingOrderScheduler(),
+		External:      NewExternalPhaseController(),
+		Prediction:    NewArrivalPredictor(),
+		Emissions:     NewEmissionModel(),
+		EcoDriving:    NewEcoDriver(),
+		Connectivity:  NewConnectivityModel(),
+		V2X:           NewV2XChannel(),
+		Agents:        NewAgentCoordinator(),
+		SizePolicy:    NewPlatoonSizePolicy(),
+		Divergence:    NewDivergencePlanner(),
+		Dilemma:       NewDilemmaZoneGuard(),
+		Reservations:  NewReservationManager(),
+
+		StringStability: NewStringStabilityAnalyzer(),
+		VehicleClasses:  DefaultVehicleClasses(),
+
+		PriorityPolicy:    DefaultPriorityPolicy(),
+		PriorityGrants:    make([]*PriorityGrant, 0),
+		MaxPriorityGrants: 200,
 	}
 }
 
+func (tm *TrafficManager) SetAlgorithm(algorithm string) error {
+	switch algorithm {
+	case AlgorithmCustom, AlgorithmSumo, AlgorithmAIM, AlgorithmFixedTime, AlgorithmActuated,
+		AlgorithmMaxPressure, AlgorithmOptimal, AlgorithmExternal:
+	default:
+		return fmt.Errorf("unknown algorithm %q", algorithm)
+	}
+
+	if algorithm != tm.Algorithm {
+		for _, intersection := range tm.Intersections {
+			intersection.CurrentControlState = nil
+		}
+	}
+
+	tm.Algorithm = algorithm
+	tm.UseCustomAlgorithm = (algorithm == AlgorithmCustom)
+	return nil
+}
+
+func (tm *TrafficManager) SimulationTime() float64 {
+	return float64(tm.TimeStep) * tm.StepLength
+}
+
+func (tm *TrafficManager) SimulationClock() time.Time {
+	return SimulationEpoch.Add(time.Duration(tm.SimulationTime() * float64(time.Second)))
+}
+
 func (tm *TrafficManager) UpdateVehicleData(vehicleData map[string]map[string]interface{}) {
 	existingVehicles := make(map[string]bool)
+	vehicleData = tm.TransmitTelemetry(vehicleData)
 
 	for id, data := range vehicleData {
 		existingVehicles[id] = true
@@ -77,12 +191,21 @@ func (tm *TrafficManager) UpdateVehicleData(vehicleData map[string]map[string]in
 		pos := data["pos"].(float64)
 		speed := data["speed"].(float64)
 		edge := data["edge"].(string)
+		route := parseRoute(data["route"])
+		vehicleType, _ := data["type"].(string)
 
 		if v, exists := tm.Vehicles[id]; exists {
+			v.Acceleration = (speed - v.Speed) / tm.StepLength
 			v.Lane = lane
 			v.Pos = pos
 			v.Speed = speed
 			v.Edge = edge
+			if len(route) > 0 {
+				v.Route = route
+			}
+			if vehicleType != "" {
+				v.Type = vehicleType
+			}
 
 			v.AtIntersection = tm.isVehicleAtIntersection(v)
 		} else {
@@ -99,10 +222,13 @@ func (tm *TrafficManager) UpdateVehicleData(vehicleData map[string]map[string]in
 				NextEdge:          "",
 				TurnDirection:     "",
 				AtIntersection:    tm.isVehicleAtIntersection(&models.Vehicle{Edge: edge}),
-				LastSpeedChange:   time.Now(),
+				LastSpeedChange:   tm.SimulationClock(),
 				StablePlatoonTime: 0,
 				ReactionTime:      0.5,
+				Route:             route,
+				Type:              vehicleType,
 			}
+			tm.registerConnectivity(tm.Vehicles[id], data)
 		}
 	}
 
@@ -131,19 +257,49 @@ func (tm *TrafficManager) UpdateVehicleData(vehicleData map[string]map[string]in
 	tm.measureTrafficDensity()
 }
 
+func parseRoute(raw interface{}) []string {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	route := make([]string, 0, len(items))
+	for _, item := range items {
+		if edge, ok := item.(string); ok {
+			route = append(route, edge)
+		}
+	}
+
+	return route
+}
+
 func (tm *TrafficManager) measureTrafficDensity() {
-	now := time.Now()
+	now := tm.SimulationClock()
 	if now.Sub(tm.LastTrafficMeasurement).Seconds() < 2.0 {
 		return
 	}
 
 	tm.LastTrafficMeasurement = now
 	edgeVehicleCounts := make(map[string]int)
+	tm.EdgeVehicleCounts = make(map[string]int)
+	tm.MovementVehicleCounts = make(map[models.Movement]int)
+	junctions := tm.getEdgeJunctions()
 
 	for _, vehicle := range tm.Vehicles {
 		if vehicle.Edge != "" && !vehicle.AtIntersection {
 			edgeVehicleCounts[vehicle.Edge]++
 		}
+
+		if vehicle.Edge != "" && vehicle.Edge[0] != ':' {
+			tm.EdgeVehicleCounts[vehicle.Edge]++
+
+			if _, isApproach := junctions[vehicle.Edge]; isApproach {
+				tm.MovementVehicleCounts[models.Movement{
+					Edge:      vehicle.Edge,
+					Direction: tm.getVehicleDirection(vehicle),
+				}]++
+			}
+		}
 	}
 
 	for edge, count := range edgeVehicleCounts {
@@ -163,20 +319,8 @@ func (tm *TrafficManager) updateIntersectionStatus() {
 		if vehicle.AtIntersection {
 			parts := strings.Split(vehicle.Edge, "_")
 			if len(parts) > 0 && len(parts[0]) > 0 && parts[0][0] == ':' {
-				intersectionID := parts[0]
-
-				if _, exists := tm.Intersections[intersectionID]; !exists {
-					tm.Intersections[intersectionID] = &models.Intersection{
-						ID:                  intersectionID,
-						InternalID:          intersectionID,
-						Edges:               []string{},
-						Vehicles:            []string{},
-						LastPlatoonPassTime: time.Now().Add(-10 * time.Second),
-					}
-				}
-
-				tm.Intersections[intersectionID].Vehicles = append(
-					tm.Intersections[intersectionID].Vehicles, id)
+				intersection := tm.getOrCreateIntersection(parts[0])
+				intersection.Vehicles = append(intersection.Vehicles, id)
 			}
 		}
 	}
@@ -184,27 +328,82 @@ func (tm *TrafficManager) updateIntersectionStatus() {
 	tm.cleanExpiredReservations()
 }
 
+func (tm *TrafficManager) getOrCreateIntersection(intersectionID string) *models.Intersection {
+	if intersection, exists := tm.Intersections[intersectionID]; exists {
+		return intersection
+	}
+
+	intersection := &models.Intersection{
+		ID:                  intersectionID,
+		InternalID:          intersectionID,
+		Edges:               []string{},
+		Vehicles:            []string{},
+		LastPlatoonPassTime: tm.SimulationClock().Add(-10 * time.Second),
+	}
+	tm.Intersections[intersectionID] = intersection
+
+	return intersection
+}
+
 func (tm *TrafficManager) cleanExpiredReservations() {
-	now := time.Now()
+	now := tm.SimulationClock()
 	for id, reservation := range tm.IntersectionReservations {
 		if now.After(reservation.EndTime) {
-			delete(tm.IntersectionReservations, id)
+			tm.expireReservation(id)
 		}
 	}
 }
 
 func (tm *TrafficManager) Update() {
 	tm.TimeStep++
+	tm.PredictArrivals()
 
-	if tm.UseCustomAlgorithm {
+	switch tm.Algorithm {
+	case AlgorithmCustom:
 		tm.UpdatePlatoons()
 		tm.EstimatePlatoonStability()
 		tm.ReservePlatoonIntersectionSlots()
 		tm.ManageIntersections()
 		tm.SynchronizeSpeeds()
 		tm.AdjustSpeedForTrafficDensity()
-	} else {
-	} //sumo stuff? I guess
+		tm.ScheduleArrivals()
+		tm.PlanPlatoonFormation()
+		tm.applyManeuverSpeeds()
+		tm.EnforceBoundedWait()
+	case AlgorithmAIM:
+		tm.UpdatePlatoons()
+		tm.EstimatePlatoonStability()
+		tm.SynchronizeSpeeds()
+		tm.applyManeuverSpeeds()
+		tm.ManageAIM()
+	case AlgorithmOptimal:
+		tm.UpdatePlatoons()
+		tm.EstimatePlatoonStability()
+		tm.SynchronizeSpeeds()
+		tm.applyManeuverSpeeds()
+		tm.ScheduleCrossingOrder()
+		tm.EnforceBoundedWait()
+	case AlgorithmFixedTime, AlgorithmActuated:
+		tm.updateLeaderRelationships()
+		tm.applyFreeFlowSpeeds()
+		tm.ManageSignals(tm.Algorithm == AlgorithmActuated)
+	case AlgorithmMaxPressure:
+		tm.updateLeaderRelationships()
+		tm.applyFreeFlowSpeeds()
+		tm.ManageMaxPressure()
+	case AlgorithmExternal:
+		tm.updateLeaderRelationships()
+		tm.applyFreeFlowSpeeds()
+		tm.ManageExternalPhases()
+	case AlgorithmSumo: //sumo stuff? I guess
+	}
+
+	tm.ApplyEcoDriving()
+	tm.ManagePreemption()
+	tm.SuperviseCommands()
+	tm.ShapeCommands()
+	tm.RecordStringStability()
+	tm.ComputeEmissions()
 
 	if tm.BenchmarkMode {
 		tm.UpdateVehicleThroughput()
@@ -253,6 +452,8 @@ func (tm *TrafficManager) EstimatePlatoonStability() {
 }
 
 func (tm *TrafficManager) ReservePlatoonIntersectionSlots() {
+	tm.RenegotiateReservations()
+
 	for _, platoon := range tm.Platoons {
 		if len(platoon.VehicleIDs) < 3 || platoon.StabilityRatio < 0.6 {
 			continue
@@ -273,34 +474,62 @@ func (tm *TrafficManager) ReservePlatoonIntersectionSlots() {
 			continue
 		}
 
-		estimatedArrivalTime := tm.estimateArrivalTime(leader, distanceToIntersection)
-		reservationID := fmt.Sprintf("%s_%s", platoon.ID, nextIntersection.ID)
-
-		if _, exists := tm.IntersectionReservations[reservationID]; exists {
-			continue
+		groups := tm.getPlatoonMovementGroups(platoon)
+		if len(groups) == 0 {
+			groups = []*MovementGroup{{
+				Direction:  leader.TurnDirection,
+				VehicleIDs: platoon.VehicleIDs,
+				Distance:   distanceToIntersection,
+			}}
 		}
 
-		passingTime := float64(len(platoon.VehicleIDs)) * 1.5
-		reservation := &models.IntersectionReservation{
-			ID:             reservationID,
-			IntersectionID: nextIntersection.ID,
-			PlatoonID:      platoon.ID,
-			StartTime:      estimatedArrivalTime,
-			EndTime:        estimatedArrivalTime.Add(time.Duration(passingTime) * time.Second),
-			EdgeFrom:       leader.Edge,
-			Direction:      leader.TurnDirection,
-		}
+		for index, group := range groups {
+			reservationID := fmt.Sprintf("%s_%s", platoon.ID, nextIntersection.ID)
+			if index > 0 {
+				reservationID = fmt.Sprintf("%s_%s_%d", platoon.ID, nextIntersection.ID, index)
+			}
 
-		if !tm.hasConflictingReservation(reservation) {
-			tm.IntersectionReservations[reservationID] = reservation
-			nextIntersection.HasReservation = true
-			log.Printf("reserved intersection %s for platoon %s, arrival at %v",
-				nextIntersection.ID, platoon.ID, estimatedArrivalTime)
+			if _, exists := tm.IntersectionReservations[reservationID]; exists {
+				continue
+			}
+
+			if tm.isReservationBlocked(leader.ID, reservationID) {
+				continue
+			}
+
+			head, exists := tm.Vehicles[group.VehicleIDs[0]]
+			if !exists {
+				continue
+			}
+
+			estimatedArrivalTime := tm.estimateArrivalTime(head, group.Distance)
+			passingTime := float64(len(group.VehicleIDs)) * 1.5
+			reservation := &models.IntersectionReservation{
+				ID:             reservationID,
+				IntersectionID: nextIntersection.ID,
+				PlatoonID:      platoon.ID,
+				StartTime:      estimatedArrivalTime,
+				EndTime:        estimatedArrivalTime.Add(time.Duration(passingTime) * time.Second),
+				EdgeFrom:       leader.Edge,
+				Direction:      group.Direction,
+			}
+
+			if !tm.hasConflictingReservation(reservation) {
+				tm.IntersectionReservations[reservationID] = reservation
+				tm.trackReservation(reservation, leader.ID)
+				nextIntersection.HasReservation = true
+				log.Printf("reserved intersection %s for platoon %s going %s, arrival at %v",
+					nextIntersection.ID, platoon.ID, group.Direction, estimatedArrivalTime)
+			}
 		}
 	}
 }
 
 func (tm *TrafficManager) hasConflictingReservation(newReservation *models.IntersectionReservation) bool {
+	if tm.conflictsWithPreemption(newReservation.IntersectionID, newReservation.EdgeFrom, newReservation.Direction) {
+		return true
+	}
+
 	for _, existing := range tm.IntersectionReservations {
 		if existing.IntersectionID != newReservation.IntersectionID {
 			continue
@@ -352,10 +581,10 @@ func (tm *TrafficManager) estimateDistanceToIntersection(vehicle *models.Vehicle
 
 func (tm *TrafficManager) estimateArrivalTime(vehicle *models.Vehicle, distance float64) time.Time {
 	if vehicle.Speed < 1.0 {
-		return time.Now().Add(time.Duration(distance / 5.0 * float64(time.Second)))
+		return tm.SimulationClock().Add(time.Duration(distance / 5.0 * float64(time.Second)))
 	}
 
-	return time.Now().Add(time.Duration(distance / vehicle.Speed * float64(time.Second)))
+	return tm.SimulationClock().Add(time.Duration(distance / vehicle.Speed * float64(time.Second)))
 }
 
 func (tm *TrafficManager) AdjustSpeedForTrafficDensity() {
@@ -405,14 +634,74 @@ func (tm *TrafficManager) AdjustSpeedForTrafficDensity() {
 func (tm *TrafficManager) PrepareCommands() map[string]interface{} {
 	commands := make(map[string]interface{})
 
-	commands["speeds"] = tm.GetDesiredSpeeds()
+	commands["speeds"] = tm.TransmitCommands(tm.GetDesiredSpeeds())
 	commands["platoons"] = tm.GetPlatoonsForVisualization()
+	commands["advisories"] = tm.GetFormationAdvisories()
 	commands["stats"] = map[string]interface{}{
 		"time_step":          tm.TimeStep,
 		"vehicle_count":      len(tm.Vehicles),
 		"platoon_count":      len(tm.Platoons),
 		"intersection_count": len(tm.Intersections),
 		"reservations_count": len(tm.IntersectionReservations),
+		"algorithm":          tm.Algorithm,
+		"maneuvers_active":   len(tm.Maneuvers.Active),
+		"maneuvers_done":     tm.Maneuvers.Completed,
+		"maneuvers_aborted":  tm.Maneuvers.Aborted,
+		"limit_hits": map[string]int{
+			"accel": tm.Shaper.StepAccelLimits,
+			"decel": tm.Shaper.StepDecelLimits,
+			"jerk":  tm.Shaper.StepJerkLimits,
+		},
+		"safety_interventions": tm.Safety.StepInterventions,
+		"preemptions_active":   len(tm.Preemption.Active),
+		"priority_policy":      tm.PriorityPolicy.Name,
+		"fairness_grants":      tm.Fairness.Grants,
+		"crossing_order": map[string]int{
+			"optimal":   tm.CrossingOrder.Optimal,
+			"fallbacks": tm.CrossingOrder.Fallbacks,
+			"nodes":     tm.CrossingOrder.Nodes,
+		},
+		"arrival_forecast": tm.summarizeArrivalForecast(),
+		"eco_driving":      tm.EcoDriving.Enabled,
+		"connected": map[string]int{
+			"connected":     tm.Connectivity.Connected,
+			"non_connected": tm.Connectivity.NonConnected,
+		},
+		"agents": tm.summarizeAgents(),
+		"platoon_size": map[string]interface{}{
+			"limits":   tm.SizePolicy.Limits,
+			"splits":   tm.SizePolicy.Splits,
+			"deferred": tm.SizePolicy.Deferred,
+		},
+		"reservations": map[string]int{
+			"shifted":   tm.Reservations.Shifted,
+			"cancelled": tm.Reservations.Cancelled,
+			"no_shows":  tm.Reservations.NoShows,
+			"blocked":   tm.Reservations.Blocked,
+		},
+		"dilemma_zone": map[string]int{
+			"cleared":  tm.Dilemma.Cleared,
+			"stopped":  tm.Dilemma.Stopped,
+			"all_reds": tm.Dilemma.AllReds,
+		},
+		"divergence": map[string]interface{}{
+			"groups":   tm.Divergence.Groups,
+			"splits":   tm.Divergence.Splits,
+			"deferred": tm.Divergence.Deferred,
+		},
+		"v2x": map[string]interface{}{
+			"sent":         tm.V2X.StepSent,
+			"delivered":    tm.V2X.StepDelivered,
+			"lost":         tm.V2X.StepLost,
+			"out_of_range": tm.V2X.StepOutOfRange,
+			"stale":        tm.V2X.StaleVehicles,
+			"age":          tm.V2X.AverageAge,
+		},
+		"emissions": map[string]float64{
+			"co2_mg":  tm.Emissions.StepCO2Mg,
+			"nox_mg":  tm.Emissions.StepNOxMg,
+			"fuel_ml": tm.Emissions.StepFuelMl,
+		},
 	}
 
 	return commands
@@ -433,7 +722,7 @@ func (tm *TrafficManager) updateVehicleWaitTimes() {
 func (tm *TrafficManager) AddVehicle(vehicle *models.Vehicle) {
 	tm.Vehicles[vehicle.ID] = vehicle
 	tm.TotalCreatedVehicles++
-	vehicle.CreationTime = time.Now()
+	vehicle.CreationTime = tm.SimulationClock()
 }
 
 func (tm *TrafficManager) RemoveVehicle(vehicleID string) {
@@ -0,0 +1,227 @@
+package manager
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+type V2XChannel struct {
+	Latency  float64
+	Jitter   float64
+	LossRate float64
+	Range    float64
+	Seed     int64
+
+	StepSent        int
+	StepDelivered   int
+	StepLost        int
+	StepOutOfRange  int
+	TotalSent       int
+	TotalDelivered  int
+	TotalLost       int
+	TotalOutOfRange int
+	StaleVehicles   int
+	AverageAge      float64
+
+	rng       *rand.Rand
+	uplink    []*v2xMessage
+	downlink  []*v2xMessage
+	telemetry map[string]*v2xMessage
+	inRange   map[string]bool
+}
+
+type v2xMessage struct {
+	vehicleID string
+	sent      float64
+	deliver   float64
+	data      map[string]interface{}
+	speed     float64
+}
+
+func NewV2XChannel() *V2XChannel {
+	return &V2XChannel{
+		Latency:   0,
+		Jitter:    0,
+		LossRate:  0,
+		Range:     0,
+		Seed:      1,
+		rng:       rand.New(rand.NewSource(1)),
+		telemetry: make(map[string]*v2xMessage),
+		inRange:   make(map[string]bool),
+	}
+}
+
+func (ch *V2XChannel) SetSeed(seed int64) {
+	ch.Seed = seed
+	ch.rng = rand.New(rand.NewSource(seed))
+}
+
+func (ch *V2XChannel) perfect() bool {
+	return ch.Latency <= 0 && ch.Jitter <= 0 && ch.LossRate <= 0 && ch.Range <= 0
+}
+
+func (ch *V2XChannel) transmit(queue []*v2xMessage, message *v2xMessage) []*v2xMessage {
+	ch.StepSent++
+	ch.TotalSent++
+
+	if ch.LossRate > 0 && ch.rng.Float64() < ch.LossRate {
+		ch.StepLost++
+		ch.TotalLost++
+		return queue
+	}
+
+	message.deliver = message.sent + ch.Latency
+	if ch.Jitter > 0 {
+		message.deliver += ch.rng.Float64() * ch.Jitter
+	}
+
+	return append(queue, message)
+}
+
+func (ch *V2XChannel) receive(queue []*v2xMessage, now float64) ([]*v2xMessage, []*v2xMessage) {
+	delivered := make([]*v2xMessage, 0)
+	pending := queue[:0]
+
+	for _, message := range queue {
+		if message.deliver <= now+1e-9 {
+			delivered = append(delivered, message)
+		} else {
+			pending = append(pending, message)
+		}
+	}
+
+	sort.SliceStable(delivered, func(i, j int) bool {
+		return delivered[i].sent < delivered[j].sent
+	})
+
+	ch.StepDelivered += len(delivered)
+	ch.TotalDelivered += len(delivered)
+	return pending, delivered
+}
+
+func (tm *TrafficManager) distanceToJunction(edge string, pos float64) float64 {
+	if len(edge) > 0 && edge[0] == ':' {
+		return 0
+	}
+
+	length, known := tm.getEdgeLengths()[edge]
+	if !known {
+		return math.Inf(1)
+	}
+
+	if _, isApproach := tm.getEdgeJunctions()[edge]; isApproach {
+		return math.Max(length-pos, 0)
+	}
+	return math.Max(pos, 0)
+}
+
+func (tm *TrafficManager) TransmitTelemetry(vehicleData map[string]map[string]interface{}) map[string]map[string]interface{} {
+	ch := tm.V2X
+	ch.StepSent, ch.StepDelivered, ch.StepLost, ch.StepOutOfRange = 0, 0, 0, 0
+	ch.StaleVehicles, ch.AverageAge = 0, 0
+
+	if ch.perfect() {
+		return vehicleData
+	}
+
+	now := tm.SimulationTime()
+
+	ids := make([]string, 0, len(vehicleData))
+	for id := range vehicleData {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	ch.inRange = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		data := vehicleData[id]
+		edge, _ := data["edge"].(string)
+		pos, _ := data["pos"].(float64)
+
+		if ch.Range > 0 && tm.distanceToJunction(edge, pos) > ch.Range {
+			ch.StepOutOfRange++
+			ch.TotalOutOfRange++
+			continue
+		}
+
+		ch.inRange[id] = true
+		ch.uplink = ch.transmit(ch.uplink, &v2xMessage{vehicleID: id, sent: now, data: data})
+	}
+
+	var delivered []*v2xMessage
+	ch.uplink, delivered = ch.receive(ch.uplink, now)
+	for _, message := range delivered {
+		if _, present := vehicleData[message.vehicleID]; !present {
+			continue
+		}
+		if last, exists := ch.telemetry[message.vehicleID]; !exists || message.sent >= last.sent {
+			ch.telemetry[message.vehicleID] = message
+		}
+	}
+
+	for id := range ch.telemetry {
+		if _, present := vehicleData[id]; !present {
+			delete(ch.telemetry, id)
+		}
+	}
+
+	view := make(map[string]map[string]interface{}, len(ch.telemetry))
+	totalAge := 0.0
+	for id, message := range ch.telemetry {
+		view[id] = message.data
+
+		age := now - message.sent
+		if age > tm.StepLength/2 {
+			ch.StaleVehicles++
+		}
+		totalAge += age
+	}
+	if len(view) > 0 {
+		ch.AverageAge = totalAge / float64(len(view))
+	}
+
+	return view
+}
+
+func (tm *TrafficManager) TransmitCommands(speeds map[string]float64) map[string]float64 {
+	ch := tm.V2X
+
+	if ch.perfect() {
+		return speeds
+	}
+
+	now := tm.SimulationTime()
+
+	ids := make([]string, 0, len(speeds))
+	for id := range speeds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if ch.Range > 0 && !ch.inRange[id] {
+			ch.StepOutOfRange++
+			ch.TotalOutOfRange++
+			continue
+		}
+		ch.downlink = ch.transmit(ch.downlink, &v2xMessage{vehicleID: id, sent: now, speed: speeds[id]})
+	}
+
+	var delivered []*v2xMessage
+	ch.downlink, delivered = ch.receive(ch.downlink, now)
+
+	applied := make(map[string]float64, len(delivered))
+	for _, message := range delivered {
+		applied[message.vehicleID] = message.speed
+	}
+
+	return applied
+}
+
+func (ch *V2XChannel) DeliveryRatio() float64 {
+	if ch.TotalSent == 0 {
+		return 1
+	}
+	return float64(ch.TotalDelivered) / float64(ch.TotalSent)
+}
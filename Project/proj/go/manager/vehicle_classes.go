@@ -0,0 +1,68 @@
+package manager
+
+import "sumo/models"
+
+const (
+	ClassCar       = "car"
+	ClassBus       = "bus"
+	ClassTruck     = "truck"
+	ClassEmergency = "emergency"
+	ClassTaxi      = "taxi"
+)
+
+func DefaultVehicleClasses() map[string]*models.VehicleClass {
+	return map[string]*models.VehicleClass{
+		ClassCar:       {ID: ClassCar, Occupancy: 1.3, PriorityWeight: 1.0},
+		ClassBus:       {ID: ClassBus, Occupancy: 30.0, PriorityWeight: 1.5},
+		ClassTruck:     {ID: ClassTruck, Occupancy: 1.0, PriorityWeight: 0.8},
+		ClassEmergency: {ID: ClassEmergency, Occupancy: 2.0, PriorityWeight: 10.0},
+		ClassTaxi:      {ID: ClassTaxi, Occupancy: 1.5, PriorityWeight: 1.2},
+	}
+}
+
+func (tm *TrafficManager) getVehicleClass(vehicle *models.Vehicle) *models.VehicleClass {
+	if class, exists := tm.VehicleClasses[vehicle.Type]; exists {
+		return class
+	}
+
+	if tm.isEmergencyVehicle(vehicle) {
+		return tm.VehicleClasses[ClassEmergency]
+	}
+
+	if vt, exists := tm.VehicleTypes[vehicle.Type]; exists && vt.Class != "" {
+		if class, exists := tm.VehicleClasses[vt.Class]; exists {
+			return class
+		}
+	}
+
+	return tm.VehicleClasses[ClassCar]
+}
+
+func (tm *TrafficManager) calculatePlatoonOccupancy(platoon *models.Platoon) (float64, float64) {
+	occupancy, weighted := 0.0, 0.0
+
+	for _, vid := range platoon.VehicleIDs {
+		vehicle, exists := tm.Vehicles[vid]
+		if !exists {
+			continue
+		}
+
+		class := tm.getVehicleClass(vehicle)
+		occupancy += class.Occupancy
+		weighted += class.Occupancy * class.PriorityWeight
+	}
+
+	return occupancy, weighted
+}
+
+func (tm *TrafficManager) calculatePersonDelay() float64 {
+	delay := 0.0
+
+	for _, vehicle := range tm.Vehicles {
+		if vehicle.Speed < 0.5 {
+			delay += tm.getVehicleClass(vehicle).Occupancy * tm.StepLength
+		}
+	}
+
+	return delay
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math"
 	"strings"
-	"time"
 
 	"sumo/models"
 )
@@ -37,7 +36,7 @@ func (tm *TrafficManager) FindVehicleAhead(vehicle *models.Vehicle) *models.Vehi
 }
 
 func (tm *TrafficManager) SynchronizeSpeeds() {
-	now := time.Now()
+	now := tm.SimulationClock()
 
 	for id, vehicle := range tm.Vehicles {
 		if vehicle.AtIntersection {
@@ -54,86 +53,33 @@ func (tm *TrafficManager) SynchronizeSpeeds() {
 			}
 		}
 
-		if vehicle.LeaderID == "" {
-			if platoonID, inPlatoon := tm.VehicleToPlatoon[id]; inPlatoon {
-				platoon, platoonExists := tm.Platoons[platoonID]
-				if platoonExists && platoon.LeaderID == id {
-					if platoon.StabilityRatio > 0.8 && len(platoon.VehicleIDs) > 3 {
-						vehicle.DesiredSpeed = math.Min(tm.StablePlatoonSpeed, vehicle.Speed+1.0)
-					} else if platoon.StabilityRatio > 0.6 {
-						vehicle.DesiredSpeed = math.Min(tm.MaxPlatoonSpeed, vehicle.Speed+0.8)
-					} else {
-						vehicle.DesiredSpeed = tm.MaxRegularSpeed
-					}
-				} else {
-					vehicle.DesiredSpeed = 13.9
-				}
-			} else {
-				vehicle.DesiredSpeed = 13.9
-			}
-			continue
-		}
-
-		leader, exists := tm.Vehicles[vehicle.LeaderID]
-		if !exists {
-			vehicle.DesiredSpeed = 13.9
-			continue
+		maxSpeed := tm.MaxRegularSpeed
+		if vehicle.PlatoonID != "" {
+			maxSpeed = tm.MaxPlatoonSpeed
 		}
 
-		currentGap := leader.Pos - vehicle.Pos
-		optimalGap := tm.calculateOptimalGap(vehicle, leader)
-
-		desiredSpeed := 0.0
-		leaderStopped := leader.Speed < 0.5
-
-		if leaderStopped {
-			if currentGap > optimalGap*3.0 {
-				desiredSpeed = 16.7
-			} else if currentGap > optimalGap*2.0 {
-				desiredSpeed = 11.1
-			} else if currentGap > optimalGap*1.5 {
-				desiredSpeed = 8.3
-			} else if currentGap > optimalGap*1.2 {
-				desiredSpeed = 5.6
-			} else if currentGap > optimalGap*1.05 {
-				desiredSpeed = 2.8
-			} else if currentGap > optimalGap {
-				desiredSpeed = 1.4
-			} else {
-				desiredSpeed = 0.0
-			}
-		} else {
-			if currentGap > optimalGap*3.0 {
-				desiredSpeed = math.Max(22.2, leader.Speed*1.5)
-			} else if currentGap > optimalGap*2.0 {
-				desiredSpeed = math.Max(19.4, leader.Speed*1.4)
-			} else if currentGap > optimalGap*1.5 {
-				desiredSpeed = math.Max(16.7, leader.Speed*1.3)
-			} else if currentGap > optimalGap*1.1 {
-				desiredSpeed = math.Min(leader.Speed*1.1, leader.Speed+2.0)
-			} else if currentGap < optimalGap*0.5 {
-				desiredSpeed = leader.Speed * 0.5
-			} else if currentGap < optimalGap*0.8 {
-				desiredSpeed = leader.Speed * 0.85
-			} else {
-				desiredSpeed = leader.Speed
+		desiredSpeed := 13.9
+		if platoonID, inPlatoon := tm.VehicleToPlatoon[id]; inPlatoon {
+			platoon, platoonExists := tm.Platoons[platoonID]
+			if platoonExists && platoon.LeaderID == id {
+				if platoon.StabilityRatio > 0.8 && len(platoon.VehicleIDs) > 3 {
+					desiredSpeed = tm.StablePlatoonSpeed
+					maxSpeed = tm.StablePlatoonSpeed
+				} else if platoon.StabilityRatio > 0.6 {
+					desiredSpeed = tm.MaxPlatoonSpeed
+				} else {
+					desiredSpeed = tm.MaxRegularSpeed
+				}
 			}
 		}
 
-		vehicle.DesiredSpeed = desiredSpeed
-
-		if vehicle.Speed < 0.5 && currentGap > optimalGap {
-			vehicle.DesiredSpeed = math.Max(5.0, desiredSpeed)
+		leader, hasLeader := tm.Vehicles[vehicle.LeaderID]
+		if hasLeader {
+			desiredSpeed = maxSpeed
 		}
 
-		vehicle.DesiredSpeed = math.Max(0.0, vehicle.DesiredSpeed)
-
-		isPlatoonMember := vehicle.PlatoonID != ""
-		if isPlatoonMember {
-			vehicle.DesiredSpeed = math.Min(tm.MaxPlatoonSpeed, vehicle.DesiredSpeed)
-		} else {
-			vehicle.DesiredSpeed = math.Min(tm.MaxRegularSpeed, vehicle.DesiredSpeed)
-		}
+		accel := tm.idmAcceleration(vehicle, leader, desiredSpeed, tm.getVehicleType(vehicle).TimeHeadway)
+		vehicle.DesiredSpeed = tm.commandSpeed(vehicle, accel, maxSpeed)
 
 		if math.Abs(vehicle.DesiredSpeed-vehicle.Speed) > 0.5 {
 			vehicle.LastSpeedChange = now
@@ -157,50 +103,12 @@ func (tm *TrafficManager) processPlatoonsIndependently() {
 			}
 
 			frontVehicle := orderedVehicles[i-1]
-			currentGap := frontVehicle.Pos - vehicle.Pos
-
-			baseOptimalGap := 7.0
-			if platoon.StabilityRatio > 0.6 {
-				baseOptimalGap = 5.0
-			}
-
-			frontStopped := frontVehicle.Speed < 0.5
-
-			if frontStopped {
-				if currentGap > baseOptimalGap*3.0 {
-					vehicle.DesiredSpeed = 16.7
-				} else if currentGap > baseOptimalGap*2.0 {
-					vehicle.DesiredSpeed = 11.1
-				} else if currentGap > baseOptimalGap*1.5 {
-					vehicle.DesiredSpeed = 8.3
-				} else if currentGap > baseOptimalGap*1.2 {
-					vehicle.DesiredSpeed = 5.6
-				} else if currentGap > baseOptimalGap*1.05 {
-					vehicle.DesiredSpeed = 2.8
-				} else if currentGap > baseOptimalGap {
-					vehicle.DesiredSpeed = 1.4
-				} else {
-					vehicle.DesiredSpeed = 0.0
-				}
-			} else {
-				if currentGap > baseOptimalGap*2.0 {
-					vehicle.DesiredSpeed = math.Max(19.4, frontVehicle.Speed*1.4)
-				} else if currentGap > baseOptimalGap*1.5 {
-					vehicle.DesiredSpeed = math.Max(16.7, frontVehicle.Speed*1.3)
-				} else if currentGap > baseOptimalGap*1.2 {
-					vehicle.DesiredSpeed = frontVehicle.Speed * 1.2
-				} else if currentGap < baseOptimalGap*0.6 {
-					vehicle.DesiredSpeed = frontVehicle.Speed * 0.6
-				} else if currentGap < baseOptimalGap*0.8 {
-					vehicle.DesiredSpeed = frontVehicle.Speed * 0.8
-				} else {
-					vehicle.DesiredSpeed = frontVehicle.Speed
-				}
+			if frontVehicle.Edge != vehicle.Edge {
+				continue
 			}
 
-			if vehicle.Speed < 0.5 && currentGap > baseOptimalGap {
-				vehicle.DesiredSpeed = math.Max(5.0, vehicle.DesiredSpeed)
-			}
+			accel := tm.caccAcceleration(vehicle, frontVehicle, tm.MaxPlatoonSpeed)
+			vehicle.DesiredSpeed = tm.commandSpeed(vehicle, accel, tm.MaxPlatoonSpeed)
 		}
 	}
 }
@@ -229,29 +137,27 @@ func (tm *TrafficManager) getOrderedPlatoonVehicles(platoon *models.Platoon) []*
 }
 
 func (tm *TrafficManager) calculateOptimalGap(follower, leader *models.Vehicle) float64 {
-	baseGap := tm.FollowingGap
+	vt := tm.getVehicleType(follower)
+	return vt.MinGap + tm.caccTimeGap(follower)*follower.Speed
+}
 
-	speedFactor := follower.Speed / 10.0
-	if speedFactor > 1.0 {
-		baseGap *= speedFactor
-	}
+func (tm *TrafficManager) caccTimeGap(follower *models.Vehicle) float64 {
+	timeGap := tm.getVehicleType(follower).CACCTimeGap
 
 	isPlatoonMember := follower.PlatoonID != ""
 	if isPlatoonMember && follower.StablePlatoonTime > 5.0 {
-		baseGap *= 0.7
+		timeGap *= 0.8
 	}
 
 	platoonID, inPlatoon := tm.VehicleToPlatoon[follower.ID]
 	if inPlatoon {
 		platoon, platoonExists := tm.Platoons[platoonID]
 		if platoonExists && platoon.StabilityRatio > 0.7 {
-			baseGap *= 0.8
+			timeGap *= 0.9
 		}
 	}
 
-	timeGap := follower.ReactionTime * follower.Speed
-
-	return math.Max(5.0, math.Min(baseGap, timeGap))
+	return timeGap
 }
 
 func (tm *TrafficManager) extractIntersectionID(edge string) string {
@@ -266,8 +172,15 @@ func (tm *TrafficManager) extractIntersectionID(edge string) string {
 
 func (tm *TrafficManager) GetDesiredSpeeds() map[string]float64 {
 	speeds := make(map[string]float64)
+	if tm.Algorithm == AlgorithmSumo {
+		return speeds
+	}
+
 	for id, vehicle := range tm.Vehicles {
-		speeds[id] = vehicle.DesiredSpeed
+		if !vehicle.Connected {
+			continue
+		}
+		speeds[id] = vehicle.CommandedSpeed
 	}
 	return speeds
 }
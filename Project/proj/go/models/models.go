@@ -22,6 +22,35 @@ type Vehicle struct {
 	CountedInThroughput bool      `json:"-"`
 	CreationTime        time.Time `json:"-"`
 	TravelTime          float64   `json:"-"`
+	Route               []string  `json:"route"`
+	Type                string    `json:"type"`
+	Acceleration        float64   `json:"-"`
+	CommandedAccel      float64   `json:"-"`
+	CommandedSpeed      float64   `json:"-"`
+	Connected           bool      `json:"-"`
+}
+
+type VehicleType struct {
+	ID              string
+	Class           string
+	Length          float64
+	MaxAccel        float64
+	ComfortDecel    float64
+	MaxDecel        float64
+	MaxJerk         float64
+	MinGap          float64
+	TimeHeadway     float64
+	AccelExponent   float64
+	CACCTimeGap     float64
+	CACCGapGain     float64
+	CACCSpeedGain   float64
+	CACCFeedForward float64
+}
+
+type VehicleClass struct {
+	ID             string
+	Occupancy      float64
+	PriorityWeight float64
 }
 
 type EdgeStatistics struct {
@@ -38,6 +67,24 @@ type IntersectionControlState struct {
 	PriorityStartTime   time.Time
 	MinGreenTime        int
 	MaxGreenTime        int
+	CurrentPhase        int
+	NextPhase           int
+	SignalInterval      string
+	IntervalStartTime   float64
+	LastDetectionTime   float64
+}
+
+type Movement struct {
+	Edge      string
+	Direction string
+}
+
+type SignalPhase struct {
+	Name      string
+	Movements []Movement
+	Green     float64
+	MinGreen  float64
+	MaxGreen  float64
 }
 
 type Platoon struct {
@@ -49,6 +96,19 @@ type Platoon struct {
 	StabilityRatio       float64
 	IntersectionWaitTime int
 	PriorityUntil        *time.Time
+	Maneuver             *PlatoonManeuver
+}
+
+type PlatoonManeuver struct {
+	ID               string
+	Kind             string
+	State            string
+	PlatoonID        string
+	MergingPlatoonID string
+	VehicleIDs       []string
+	StartTime        float64
+	StateStartTime   float64
+	AbortReason      string
 }
 
 type Intersection struct {
@@ -59,6 +119,9 @@ type Intersection struct {
 	HasReservation      bool
 	LastPlatoonPassTime time.Time
 	CurrentControlState *IntersectionControlState
+	PriorityEdge        string
+	ClearanceUntil      time.Time
+	ClearingVehicles    []string
 }
 
 type IntersectionReservation struct {
@@ -71,8 +134,36 @@ type IntersectionReservation struct {
 	Direction      string
 }
 
+type Point struct {
+	X float64
+	Y float64
+}
+
 const (
 	TurnLeft     = "left"
 	TurnRight    = "right"
 	TurnStraight = "straight"
 )
+
+const (
+	SignalGreen  = "green"
+	SignalYellow = "yellow"
+	SignalAllRed = "all_red"
+)
+
+const (
+	ManeuverJoin  = "join"
+	ManeuverLeave = "leave"
+	ManeuverMerge = "merge"
+	ManeuverSplit = "split"
+)
+
+const (
+	ManeuverRequested  = "requested"
+	ManeuverGapOpening = "gap_opening"
+	ManeuverClosing    = "closing"
+	ManeuverJoined     = "joined"
+	ManeuverLeaving    = "leaving"
+	ManeuverDissolved  = "dissolved"
+	ManeuverAborted    = "aborted"
+)
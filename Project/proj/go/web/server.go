@@ -7,10 +7,12 @@ import (
 	"html/template"
 	"io"
 	"log"
+	"math"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -57,6 +59,7 @@ func (s *WebServer) Start() {
 	http.HandleFunc("/api/stats", s.handleStats)
 	http.HandleFunc("/api/control", s.handleControl)
 	http.HandleFunc("/api/csv-data", s.handleCsvData)
+	http.HandleFunc("/api/forecast", s.handleForecast)
 
 	go s.broadcastMetrics()
 
@@ -162,6 +165,7 @@ func (s *WebServer) collectMetrics() map[string]interface{} {
 		"average_speed":      tm.CalculateAverageSpeed(),
 		"total_throughput":   tm.ThroughputCounter,
 		"using_custom_algo":  tm.UseCustomAlgorithm,
+		"algorithm":          tm.Algorithm,
 	}
 
 	if tm.BenchmarkMode {
@@ -192,6 +196,12 @@ func (s *WebServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(metrics)
 }
 
+func (s *WebServer) handleForecast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(s.TrafficManager.GetArrivalForecasts())
+}
+
 func (s *WebServer) handleStats(w http.ResponseWriter, r *http.Request) {
 	stats := map[string]interface{}{
 		"files": s.getStatisticsFiles(),
@@ -266,23 +276,85 @@ func (s *WebServer) handleControl(w http.ResponseWriter, r *http.Request) {
 			algo = "custom"
 		}
 
-		currentAlgoType := "sumo"
-		if s.TrafficManager.UseCustomAlgorithm {
-			currentAlgoType = "custom"
+		currentAlgoType := s.TrafficManager.Algorithm
+
+		if err := s.TrafficManager.SetAlgorithm(algo); err != nil {
+			log.Printf("failed to change algorithm: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
 		if s.TrafficManager.BenchmarkMode && len(s.TrafficManager.BenchmarkMetrics) > 0 {
 			s.TrafficManager.SaveBenchmarkResults()
 		}
 
-		s.TrafficManager.UseCustomAlgorithm = (algo == "custom")
-
 		duration := 1000
 		s.TrafficManager.StartBenchmark(duration, algo)
 
 		result["message"] = fmt.Sprintf("changed from %s to %s algorithm", currentAlgoType, algo)
 		log.Printf("changed from %s to %s algorithm", currentAlgoType, algo)
 
+	case "set_policy":
+		var policy *manager.PriorityPolicy
+		var err error
+
+		if name := r.FormValue("name"); name != "" {
+			policy, err = manager.LoadNamedPriorityPolicy(name)
+		} else {
+			policy, err = manager.ParsePriorityPolicy([]byte(r.FormValue("policy")))
+		}
+
+		if err == nil {
+			err = s.TrafficManager.SetPriorityPolicy(policy)
+		}
+
+		if err != nil {
+			log.Printf("failed to set priority policy: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result["message"] = fmt.Sprintf("priority policy set to %s", policy.Name)
+
+	case "set_eco":
+		s.TrafficManager.EcoDriving.Enabled = r.FormValue("enabled") == "true"
+
+		result["message"] = fmt.Sprintf("eco-driving enabled: %t", s.TrafficManager.EcoDriving.Enabled)
+		log.Printf("eco-driving enabled: %t", s.TrafficManager.EcoDriving.Enabled)
+
+	case "set_v2x":
+		channel := s.TrafficManager.V2X
+		settings := map[string]*float64{
+			"latency": &channel.Latency,
+			"jitter":  &channel.Jitter,
+			"loss":    &channel.LossRate,
+			"range":   &channel.Range,
+		}
+
+		values := make(map[string]float64)
+		for name := range settings {
+			raw := r.FormValue(name)
+			if raw == "" {
+				continue
+			}
+
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || value < 0 || (name == "loss" && value > 1) {
+				http.Error(w, fmt.Sprintf("invalid %s: %q", name, raw), http.StatusBadRequest)
+				return
+			}
+			values[name] = value
+		}
+
+		for name, value := range values {
+			*settings[name] = value
+		}
+
+		result["message"] = fmt.Sprintf("v2x channel set to latency %.2fs, jitter %.2fs, loss %.2f, range %.0fm",
+			channel.Latency, channel.Jitter, channel.LossRate, channel.Range)
+		log.Printf("v2x channel set to latency %.2fs, jitter %.2fs, loss %.2f, range %.0fm",
+			channel.Latency, channel.Jitter, channel.LossRate, channel.Range)
+
 	default:
 		http.Error(w, "Invalid action", http.StatusBadRequest)
 		return
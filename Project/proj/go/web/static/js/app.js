@@ -67,8 +67,8 @@ document.addEventListener('DOMContentLoaded', () => {
                 if (!simulationStarted && data.time_step > 0) {
                     simulationStarted = true;
                     
-                    if (data.using_custom_algo !== undefined) {
-                        updateAlgorithmDisplay(data.using_custom_algo);
+                    if (data.algorithm !== undefined) {
+                        updateAlgorithmDisplay(data.algorithm);
                     }
                 }
             } catch (e) {
@@ -77,12 +77,17 @@ document.addEventListener('DOMContentLoaded', () => {
         };
     }
     
-    function updateAlgorithmDisplay(isCustom) {
-        const algoName = isCustom ? 'Custom' : 'SUMO';
-        const algoClass = isCustom ? 'custom' : 'sumo';
+    function algorithmName(algorithm) {
+        const option = document.querySelector(`#algorithm-select option[value="${algorithm}"]`);
+        return option ? option.textContent : algorithm;
+    }
+
+    function updateAlgorithmDisplay(algorithm) {
+        const algoName = algorithmName(algorithm);
+        const algoClass = algorithm === 'custom' ? 'custom' : 'sumo';
         
         document.getElementById('algorithm').textContent = algoName;
-        document.getElementById('algorithm-select').value = isCustom ? 'custom' : 'sumo';
+        document.getElementById('algorithm-select').value = algorithm;
         
         const badge = document.getElementById('current-algorithm-badge');
         badge.textContent = algoName;
@@ -213,7 +218,7 @@ document.addEventListener('DOMContentLoaded', () => {
     
     function updateMetricsDisplay(data) {
         document.getElementById('time-step').textContent = data.time_step || 0;
-        document.getElementById('algorithm').textContent = data.using_custom_algo ? 'Custom' : 'SUMO';
+        document.getElementById('algorithm').textContent = algorithmName(data.algorithm || 'custom');
         document.getElementById('vehicle-count').textContent = data.vehicle_count || 0;
         document.getElementById('platoon-count').textContent = data.platoon_count || 0;
         document.getElementById('average-speed').textContent = `${(data.average_speed || 0).toFixed(1)} m/s`;
@@ -619,7 +624,7 @@ document.addEventListener('DOMContentLoaded', () => {
             console.log('control command response:', data);
             
             if (action === 'change_algo') {
-                updateAlgorithmDisplay(params.algorithm === 'custom');
+                updateAlgorithmDisplay(params.algorithm);
                 
                 metricsHistory = {
                     timestamps: [],
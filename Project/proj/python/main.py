@@ -45,6 +45,8 @@ def gather_vehicle_data():
                 "pos": traci.vehicle.getLanePosition(vid),
                 "speed": traci.vehicle.getSpeed(vid),
                 "edge": traci.vehicle.getRoadID(vid),
+                "route": list(traci.vehicle.getRoute(vid)),
+                "type": traci.vehicle.getTypeID(vid),
             }
         except traci.TraCIException:
             continue